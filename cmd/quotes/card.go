@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	cardOutput     string
+	cardType       string
+	cardSize       string
+	cardBackground string
+	cardForeground string
+)
+
+// cardSizes holds the --size presets, as width and height in pixels
+var cardSizes = map[string][2]int{
+	"square":    {1080, 1080},
+	"banner":    {1500, 500},
+	"wallpaper": {1920, 1080},
+}
+
+// Custom --size dimensions must fall within these bounds
+const (
+	minCardDimension = 100
+	maxCardDimension = 4096
+)
+
+// cardBackgrounds holds the named --background choices, as the stops of a
+// diagonal gradient. A single stop is a solid colour.
+var cardBackgrounds = map[string][]string{
+	"midnight": {"#0f2027", "#203a43", "#2c5364"},
+	"sunset":   {"#ff7e5f", "#feb47b"},
+	"ocean":    {"#2193b0", "#6dd5ed"},
+	"forest":   {"#134e5e", "#71b280"},
+	"paper":    {"#f8f4ea"},
+	"ink":      {"#111111"},
+}
+
+// cardFontFamily is the font SVG cards ask for
+const cardFontFamily = "Helvetica, Arial, sans-serif"
+
+// cardMetrics describes a font as laid out on a card: how far each column of
+// text advances and how tall each line is, per unit of size
+type cardMetrics struct {
+	advance    float64
+	lineHeight float64
+}
+
+var (
+	// pngMetrics measure the bitmap font in pixels per unit of scale, with
+	// a column between glyphs and two rows above and below them
+	pngMetrics = cardMetrics{advance: glyphWidth + 1, lineHeight: glyphHeight + 4}
+
+	// svgMetrics measure a sans-serif font in multiples of the font size,
+	// erring wide so lines don't overflow
+	svgMetrics = cardMetrics{advance: 0.55, lineHeight: 1.35}
+)
+
+// Largest sizes tried when fitting text to a card: a pixel scale for PNG and
+// a font size for SVG
+const (
+	maxPNGScale    = 16
+	maxSVGFontSize = 72
+)
+
+// cardStyle is how a card looks
+type cardStyle struct {
+	width, height int
+	background    []color.NRGBA
+	foreground    color.NRGBA
+}
+
+// cardLayout is a quote broken into lines at sizes that fit a card
+type cardLayout struct {
+	textSize, attributionSize int
+	text, attribution         []string
+}
+
+// newCardCommand creates the card subcommand, which renders a quote as an
+// image
+func newCardCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "card",
+		Short: "Render a quote as an SVG or PNG image",
+		Long: `Render a quote as an image to share: its text, word-wrapped as large as fits,
+above its attribution, on a solid or gradient background.
+
+The image type follows the extension of --output unless --type is given, and
+defaults to SVG. The quote is picked like the root command, so --seed, --deck
+and the filter flags work the same way, and the same seed always renders the
+same image.
+
+Sizes:
+  square      1080x1080
+  banner      1500x500
+  wallpaper   1920x1080
+  WxH         any other size, from 100 to 4096 pixels a side
+
+Backgrounds are a name (` + strings.Join(styleNames(cardBackgrounds), ", ") + `), a colour
+such as "#1e1e2e", or comma-separated colours for a diagonal gradient.`,
+		Args: cobra.NoArgs,
+		RunE: runCard,
+	}
+
+	cmd.Flags().StringVarP(&cardOutput, "output", "o", "", "File to write the image to (default stdout)")
+	cmd.Flags().StringVar(&cardType, "type", "", "Image type: svg|png (default: from the --output extension, else svg)")
+	cmd.Flags().StringVar(&cardSize, "size", "square", "Image size: "+strings.Join(styleNames(cardSizes), "|")+", or WxH")
+	cmd.Flags().StringVar(&cardBackground, "background", "midnight", "Background name, colour, or comma-separated gradient colours")
+	cmd.Flags().StringVar(&cardForeground, "foreground", "", "Text colour (default: white or near-black, whichever suits the background)")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Random seed for reproducibility")
+	cmd.Flags().BoolVar(&useDeck, "deck", false, "Deal from a persistent shuffled deck so quotes don't repeat across runs")
+
+	return cmd
+}
+
+// runCard picks a quote and writes it as an image
+func runCard(cmd *cobra.Command, args []string) error {
+	imageType, err := cardImageType(cardType, cardOutput)
+	if err != nil {
+		return err
+	}
+	style, err := newCardStyle(cardSize, cardBackground, cardForeground)
+	if err != nil {
+		return err
+	}
+	if imageType == "png" && cardOutput == "" {
+		if _, isTerminal := terminalWidth(os.Stdout); isTerminal {
+			return fmt.Errorf("not writing a PNG to the terminal; use --output or redirect it")
+		}
+	}
+
+	count = 1
+	selected, err := pickQuotes()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if imageType == "png" {
+		if data, err = renderCardPNG(selected[0], style); err != nil {
+			return err
+		}
+	} else {
+		data = []byte(renderCardSVG(selected[0], style))
+	}
+
+	if cardOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(cardOutput, data, 0644)
+}
+
+// cardImageType resolves --type, falling back to the extension of output
+func cardImageType(imageType, output string) (string, error) {
+	if imageType == "" {
+		imageType = "svg"
+		if strings.EqualFold(filepath.Ext(output), ".png") {
+			imageType = "png"
+		}
+	}
+	if imageType != "svg" && imageType != "png" {
+		return "", fmt.Errorf("invalid type: %s (must be one of: svg, png)", imageType)
+	}
+	return imageType, nil
+}
+
+// newCardStyle parses the --size, --background and --foreground flags
+func newCardStyle(size, background, foreground string) (cardStyle, error) {
+	var style cardStyle
+	var err error
+
+	if style.width, style.height, err = parseCardSize(size); err != nil {
+		return style, err
+	}
+	if style.background, err = parseBackground(background); err != nil {
+		return style, err
+	}
+
+	if foreground == "" {
+		style.foreground = contrastingColor(style.background)
+	} else if style.foreground, err = parseColor(foreground); err != nil {
+		return style, fmt.Errorf("invalid foreground: %w", err)
+	}
+
+	return style, nil
+}
+
+// parseCardSize returns the width and height of a --size preset or WxH value
+func parseCardSize(size string) (int, int, error) {
+	if preset, ok := cardSizes[size]; ok {
+		return preset[0], preset[1], nil
+	}
+
+	w, h, ok := strings.Cut(strings.ToLower(size), "x")
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if !ok || errW != nil || errH != nil {
+		return 0, 0, fmt.Errorf("invalid size: %s (must be one of: %s, or WxH)", size, strings.Join(styleNames(cardSizes), ", "))
+	}
+	if width < minCardDimension || width > maxCardDimension || height < minCardDimension || height > maxCardDimension {
+		return 0, 0, fmt.Errorf("invalid size: %s (sides must be %d-%d pixels)", size, minCardDimension, maxCardDimension)
+	}
+	return width, height, nil
+}
+
+// parseBackground returns the gradient stops of a named background or a
+// comma-separated list of colours
+func parseBackground(background string) ([]color.NRGBA, error) {
+	stops, ok := cardBackgrounds[background]
+	if !ok {
+		stops = strings.Split(background, ",")
+	}
+
+	colors := make([]color.NRGBA, len(stops))
+	for i, stop := range stops {
+		c, err := parseColor(strings.TrimSpace(stop))
+		if err != nil {
+			return nil, fmt.Errorf("invalid background: %w (use a name: %s, or colours)", err, strings.Join(styleNames(cardBackgrounds), ", "))
+		}
+		colors[i] = c
+	}
+	return colors, nil
+}
+
+// parseColor parses a #rgb or #rrggbb colour
+func parseColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || !strings.HasPrefix(s, "#") || err != nil {
+		return color.NRGBA{}, fmt.Errorf("%q is not a #rgb or #rrggbb colour", s)
+	}
+	return color.NRGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xFF}, nil
+}
+
+// contrastingColor returns white for dark backgrounds and near-black for
+// light ones, judged by the average relative luminance of the stops
+func contrastingColor(background []color.NRGBA) color.NRGBA {
+	var luminance float64
+	for _, c := range background {
+		luminance += (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 255
+	}
+	if luminance/float64(len(background)) > 0.55 {
+		return color.NRGBA{R: 0x1F, G: 0x23, B: 0x28, A: 0xFF}
+	}
+	return color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+}
+
+// hexColor formats c as #rrggbb
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// gradientAt returns the colour a fraction t of the way along the stops
+func gradientAt(stops []color.NRGBA, t float64) color.NRGBA {
+	if len(stops) == 1 || t <= 0 {
+		return stops[0]
+	}
+	if t >= 1 {
+		return stops[len(stops)-1]
+	}
+
+	pos := t * float64(len(stops)-1)
+	i := int(pos)
+	frac := pos - float64(i)
+	mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*frac + 0.5) }
+	a, b := stops[i], stops[i+1]
+	return color.NRGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 0xFF}
+}
+
+// cardMargin returns the space left around the text of a card
+func cardMargin(style cardStyle) int {
+	return min(style.width, style.height) * 8 / 100
+}
+
+// layoutCard wraps the quote at the largest size, up to maxSize, at which its
+// text and attribution fit in a box of the given dimensions
+func layoutCard(q Quote, boxWidth, boxHeight int, m cardMetrics, maxSize int) cardLayout {
+	var layout cardLayout
+	for size := maxSize; size >= 1; size-- {
+		attributionSize := max(size*3/5, 1)
+		layout = cardLayout{
+			textSize:        size,
+			attributionSize: attributionSize,
+			text:            wrapLines(m.columns(boxWidth, size), q.Text),
+			attribution:     wrapLines(m.columns(boxWidth, attributionSize), "— "+attribution(q, q.Source)),
+		}
+		if layout.fits(boxWidth, boxHeight, m) {
+			break
+		}
+	}
+	return layout
+}
+
+// columns returns how many columns of text at size fit in width
+func (m cardMetrics) columns(width, size int) int {
+	return max(int(float64(width)/(m.advance*float64(size))), 1)
+}
+
+// fits reports whether the layout fits in a box of the given dimensions
+func (l cardLayout) fits(boxWidth, boxHeight int, m cardMetrics) bool {
+	if l.height(m) > float64(boxHeight) {
+		return false
+	}
+	for _, line := range l.text {
+		if float64(displayWidth(line))*m.advance*float64(l.textSize) > float64(boxWidth) {
+			return false
+		}
+	}
+	for _, line := range l.attribution {
+		if float64(displayWidth(line))*m.advance*float64(l.attributionSize) > float64(boxWidth) {
+			return false
+		}
+	}
+	return true
+}
+
+// height returns how tall the layout is: the text, a gap of one attribution
+// line, then the attribution
+func (l cardLayout) height(m cardMetrics) float64 {
+	text := m.lineHeight * float64(l.textSize*len(l.text))
+	attribution := m.lineHeight * float64(l.attributionSize*(len(l.attribution)+1))
+	return text + attribution
+}
+
+// renderCardSVG draws a quote card as an SVG document
+func renderCardSVG(q Quote, style cardStyle) string {
+	margin := cardMargin(style)
+	layout := layoutCard(q, style.width-2*margin, style.height-2*margin, svgMetrics, maxSVGFontSize)
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", style.width, style.height, style.width, style.height)
+
+	fill := hexColor(style.background[0])
+	if len(style.background) > 1 {
+		fill = "url(#background)"
+		result.WriteString("<defs>\n<linearGradient id=\"background\" x1=\"0\" y1=\"0\" x2=\"1\" y2=\"1\">\n")
+		for i, stop := range style.background {
+			offset := float64(i) / float64(len(style.background)-1) * 100
+			fmt.Fprintf(&result, "<stop offset=\"%s%%\" stop-color=\"%s\"/>\n", strconv.FormatFloat(offset, 'f', -1, 64), hexColor(stop))
+		}
+		result.WriteString("</linearGradient>\n</defs>\n")
+	}
+	fmt.Fprintf(&result, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", fill)
+
+	fmt.Fprintf(&result, "<g font-family=\"%s\" fill=\"%s\" text-anchor=\"middle\">\n", cardFontFamily, hexColor(style.foreground))
+	y := (float64(style.height) - layout.height(svgMetrics)) / 2
+	line := func(text string, size int, extra string) {
+		lineHeight := svgMetrics.lineHeight * float64(size)
+		// Put the baseline where the line's capitals sit centred in it
+		baseline := y + (lineHeight+0.7*float64(size))/2
+		fmt.Fprintf(&result, "<text x=\"%d\" y=\"%.0f\" font-size=\"%d\"%s>%s</text>\n", style.width/2, baseline, size, extra, html.EscapeString(text))
+		y += lineHeight
+	}
+	for _, text := range layout.text {
+		line(text, layout.textSize, "")
+	}
+	y += svgMetrics.lineHeight * float64(layout.attributionSize)
+	for _, text := range layout.attribution {
+		line(text, layout.attributionSize, " fill-opacity=\"0.8\"")
+	}
+	result.WriteString("</g>\n</svg>\n")
+
+	return result.String()
+}
+
+// renderCardPNG draws a quote card as a PNG image, in the bitmap font
+func renderCardPNG(q Quote, style cardStyle) ([]byte, error) {
+	margin := cardMargin(style)
+	layout := layoutCard(q, style.width-2*margin, style.height-2*margin, pngMetrics, maxPNGScale)
+
+	img := image.NewNRGBA(image.Rect(0, 0, style.width, style.height))
+	for y := 0; y < style.height; y++ {
+		for x := 0; x < style.width; x++ {
+			t := (float64(x)/float64(style.width-1) + float64(y)/float64(style.height-1)) / 2
+			img.SetNRGBA(x, y, gradientAt(style.background, t))
+		}
+	}
+
+	attributionColor := style.foreground
+	attributionColor.A = 0xCC
+
+	y := (style.height - int(layout.height(pngMetrics))) / 2
+	for _, line := range layout.text {
+		drawBitmapText(img, line, y, layout.textSize, style.foreground)
+		y += int(pngMetrics.lineHeight) * layout.textSize
+	}
+	y += int(pngMetrics.lineHeight) * layout.attributionSize
+	for _, line := range layout.attribution {
+		drawBitmapText(img, line, y, layout.attributionSize, attributionColor)
+		y += int(pngMetrics.lineHeight) * layout.attributionSize
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawBitmapText draws a line of text centred across img, in a line box
+// starting at top, with each font pixel scale pixels square. Wide characters
+// are drawn twice as wide.
+func drawBitmapText(img draw.Image, text string, top, scale int, c color.NRGBA) {
+	cell := int(pngMetrics.advance) * scale
+	x := (img.Bounds().Dx() - displayWidth(text)*cell + scale) / 2
+	y := top + (int(pngMetrics.lineHeight)-glyphHeight)/2*scale
+	src := image.NewUniform(c)
+
+	for _, r := range text {
+		width := runeWidth(r)
+		if width == 0 {
+			continue
+		}
+
+		glyph := glyphFor(r)
+		for row, bits := range glyph {
+			for col := 0; col < glyphWidth; col++ {
+				if bits&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				px := image.Rect(x+col*width*scale, y+row*scale, x+(col+1)*width*scale, y+(row+1)*scale)
+				draw.Draw(img, px, src, image.Point{}, draw.Over)
+			}
+		}
+		x += width * cell
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCardSize(t *testing.T) {
+	tests := []struct {
+		size          string
+		width, height int
+		wantErr       string
+	}{
+		{size: "square", width: 1080, height: 1080},
+		{size: "banner", width: 1500, height: 500},
+		{size: "wallpaper", width: 1920, height: 1080},
+		{size: "800x600", width: 800, height: 600},
+		{size: "800X600", width: 800, height: 600},
+		{size: "huge", wantErr: "must be one of: banner, square, wallpaper, or WxH"},
+		{size: "800x", wantErr: "invalid size"},
+		{size: "50x600", wantErr: "sides must be 100-4096 pixels"},
+		{size: "800x5000", wantErr: "sides must be 100-4096 pixels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			width, height, err := parseCardSize(tt.size)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseCardSize() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCardSize() unexpected error: %v", err)
+			}
+			if width != tt.width || height != tt.height {
+				t.Errorf("parseCardSize() = %dx%d, want %dx%d", width, height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestParseBackground(t *testing.T) {
+	tests := []struct {
+		background string
+		want       []string
+		wantErr    string
+	}{
+		{background: "sunset", want: []string{"#ff7e5f", "#feb47b"}},
+		{background: "paper", want: []string{"#f8f4ea"}},
+		{background: "#1e1e2e", want: []string{"#1e1e2e"}},
+		{background: "#abc", want: []string{"#aabbcc"}},
+		{background: "#000, #FFFFFF", want: []string{"#000000", "#ffffff"}},
+		{background: "plaid", wantErr: `"plaid" is not a #rgb or #rrggbb colour`},
+		{background: "#12345", wantErr: "invalid background"},
+		{background: "123456", wantErr: "invalid background"},
+		{background: "#gggggg", wantErr: "invalid background"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.background, func(t *testing.T) {
+			got, err := parseBackground(tt.background)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseBackground() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBackground() unexpected error: %v", err)
+			}
+
+			var hex []string
+			for _, c := range got {
+				hex = append(hex, hexColor(c))
+			}
+			if strings.Join(hex, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("parseBackground() = %v, want %v", hex, tt.want)
+			}
+		})
+	}
+}
+
+func TestContrastingColor(t *testing.T) {
+	tests := []struct {
+		background string
+		want       string
+	}{
+		{background: "midnight", want: "#ffffff"},
+		{background: "ink", want: "#ffffff"},
+		{background: "paper", want: "#1f2328"},
+		{background: "#ffff00", want: "#1f2328"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.background, func(t *testing.T) {
+			background, err := parseBackground(tt.background)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hexColor(contrastingColor(background)); got != tt.want {
+				t.Errorf("contrastingColor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradientAt(t *testing.T) {
+	stops := []color.NRGBA{{R: 0, A: 0xFF}, {R: 100, A: 0xFF}, {R: 200, A: 0xFF}}
+
+	tests := []struct {
+		t    float64
+		want uint8
+	}{
+		{t: -1, want: 0},
+		{t: 0, want: 0},
+		{t: 0.25, want: 50},
+		{t: 0.5, want: 100},
+		{t: 0.75, want: 150},
+		{t: 1, want: 200},
+	}
+
+	for _, tt := range tests {
+		if got := gradientAt(stops, tt.t); got.R != tt.want {
+			t.Errorf("gradientAt(%v).R = %d, want %d", tt.t, got.R, tt.want)
+		}
+	}
+}
+
+func TestLayoutCard(t *testing.T) {
+	q := Quote{Text: "Simplicity is prerequisite for reliability.", Author: "Edsger Dijkstra"}
+
+	tests := []struct {
+		name          string
+		width, height int
+		wantSize      int
+		wantText      []string
+	}{
+		{
+			name:     "one line when wide",
+			width:    4000,
+			height:   400,
+			wantSize: 15,
+			wantText: []string{"Simplicity is prerequisite for reliability."},
+		},
+		{
+			name:     "wraps when narrow",
+			width:    1000,
+			height:   600,
+			wantSize: 10,
+			wantText: []string{"Simplicity is", "prerequisite for", "reliability."},
+		},
+		{
+			name:     "smallest size when nothing fits",
+			width:    10,
+			height:   10,
+			wantSize: 1,
+			wantText: []string{"Simplicity", "is", "prerequisite", "for", "reliability."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := layoutCard(q, tt.width, tt.height, pngMetrics, maxPNGScale)
+
+			if got.textSize != tt.wantSize {
+				t.Errorf("layoutCard() textSize = %d, want %d", got.textSize, tt.wantSize)
+			}
+			if strings.Join(got.text, "|") != strings.Join(tt.wantText, "|") {
+				t.Errorf("layoutCard() text = %q, want %q", got.text, tt.wantText)
+			}
+			if got.attributionSize != max(got.textSize*3/5, 1) {
+				t.Errorf("layoutCard() attributionSize = %d for textSize %d", got.attributionSize, got.textSize)
+			}
+			if tt.wantSize > 1 && !got.fits(tt.width, tt.height, pngMetrics) {
+				t.Errorf("layoutCard() = %+v, which doesn't fit", got)
+			}
+		})
+	}
+}
+
+func TestRenderCardSVG(t *testing.T) {
+	q := Quote{Text: "Use <b> & \"quotes\"", Author: "Anon"}
+
+	t.Run("gradient", func(t *testing.T) {
+		style, err := newCardStyle("banner", "sunset", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := renderCardSVG(q, style)
+
+		for _, want := range []string{
+			`<svg xmlns="http://www.w3.org/2000/svg" width="1500" height="500" viewBox="0 0 1500 500">`,
+			`<stop offset="0%" stop-color="#ff7e5f"/>`,
+			`<stop offset="100%" stop-color="#feb47b"/>`,
+			`fill="url(#background)"`,
+			`fill="#1f2328"`,
+			`Use &lt;b&gt; &amp; &#34;quotes&#34;</text>`,
+			`fill-opacity="0.8">— Anon</text>`,
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("renderCardSVG() missing %q:\n%s", want, got)
+			}
+		}
+		if !strings.HasSuffix(got, "</svg>\n") {
+			t.Errorf("renderCardSVG() is not a complete document:\n%s", got)
+		}
+	})
+
+	t.Run("solid", func(t *testing.T) {
+		style, err := newCardStyle("square", "ink", "#f00")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := renderCardSVG(q, style)
+
+		if strings.Contains(got, "linearGradient") {
+			t.Errorf("renderCardSVG() has a gradient for a solid background:\n%s", got)
+		}
+		for _, want := range []string{`<rect width="100%" height="100%" fill="#111111"/>`, `fill="#ff0000"`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("renderCardSVG() missing %q:\n%s", want, got)
+			}
+		}
+	})
+}
+
+func TestRenderCardPNG(t *testing.T) {
+	q := Quote{Text: "Talk is cheap. Show me the code.", Author: "Linus Torvalds"}
+	style, err := newCardStyle("400x200", "#000000,#0000ff", "#ffffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := renderCardPNG(q, style)
+	if err != nil {
+		t.Fatalf("renderCardPNG() unexpected error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("renderCardPNG() is not a PNG: %v", err)
+	}
+
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+		t.Errorf("renderCardPNG() is %dx%d, want 400x200", b.Dx(), b.Dy())
+	}
+	if got := color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA); got != style.background[0] {
+		t.Errorf("top left = %v, want %v", got, style.background[0])
+	}
+	if got := color.NRGBAModel.Convert(img.At(399, 199)).(color.NRGBA); got != style.background[1] {
+		t.Errorf("bottom right = %v, want %v", got, style.background[1])
+	}
+
+	white := 0
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r == 0xFFFF {
+				white++
+			}
+		}
+	}
+	if white == 0 {
+		t.Error("renderCardPNG() drew no text")
+	}
+
+	again, err := renderCardPNG(q, style)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Error("renderCardPNG() is not deterministic")
+	}
+}
+
+func TestCardCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		args     []string
+		file     string
+		contains string
+		isPNG    bool
+		wantErr  string
+	}{
+		{
+			name:     "svg to stdout",
+			args:     []string{"card", "--author", "dijkstra", "--seed", "1"},
+			contains: "— Edsger Dijkstra</text>",
+		},
+		{
+			name:  "png to stdout",
+			args:  []string{"card", "--type", "png", "--size", "200x100", "--seed", "1"},
+			isPNG: true,
+		},
+		{
+			name:  "type from extension",
+			args:  []string{"card", "--size", "200x100", "-o", filepath.Join(dir, "card.png")},
+			file:  filepath.Join(dir, "card.png"),
+			isPNG: true,
+		},
+		{
+			name:     "svg file",
+			args:     []string{"card", "--background", "paper", "--output", filepath.Join(dir, "card.svg")},
+			file:     filepath.Join(dir, "card.svg"),
+			contains: `fill="#f8f4ea"`,
+		},
+		{
+			name:    "bad type",
+			args:    []string{"card", "--type", "gif"},
+			wantErr: "invalid type: gif",
+		},
+		{
+			name:    "bad size",
+			args:    []string{"card", "--size", "tiny"},
+			wantErr: "invalid size",
+		},
+		{
+			name:    "bad background",
+			args:    []string{"card", "--background", "plaid"},
+			wantErr: "invalid background",
+		},
+		{
+			name:    "bad foreground",
+			args:    []string{"card", "--foreground", "white"},
+			wantErr: "invalid foreground",
+		},
+		{
+			name:    "no matches",
+			args:    []string{"card", "--author", "nobody at all"},
+			wantErr: "no quotes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateSources(t)
+			output, err := executeCommand(newRootCommand(), tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.file != "" {
+				if output != "" {
+					t.Errorf("wrote %q to stdout with --output", output)
+				}
+				data, err := os.ReadFile(tt.file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				output = string(data)
+			}
+			if tt.isPNG {
+				if _, err := png.Decode(strings.NewReader(output)); err != nil {
+					t.Errorf("output is not a PNG: %v", err)
+				}
+			}
+			if !strings.Contains(output, tt.contains) {
+				t.Errorf("output missing %q:\n%s", tt.contains, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"x * * * *",
+		"* * * foo *",
+		"@fortnightly",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := parseCron(expr); err == nil {
+				t.Errorf("parseCron(%q) should fail", expr)
+			}
+		})
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", at(2026, 10, 17, 10, 7), at(2026, 10, 17, 10, 15)},
+		{"*/15 * * * *", at(2026, 10, 17, 10, 15), at(2026, 10, 17, 10, 30)},
+		{"0 9 * * mon-fri", at(2026, 10, 16, 9, 0), at(2026, 10, 19, 9, 0)},
+		{"@daily", time.Date(2026, 10, 17, 23, 59, 30, 0, time.UTC), at(2026, 10, 18, 0, 0)},
+		{"@hourly", at(2026, 12, 31, 23, 0), at(2027, 1, 1, 0, 0)},
+		{"30 8 * jan,jul *", at(2026, 10, 17, 0, 0), at(2027, 1, 1, 8, 30)},
+		{"5/20 * * * *", at(2026, 10, 17, 10, 26), at(2026, 10, 17, 10, 45)},
+		{"0 0 * * 7", at(2026, 10, 17, 12, 0), at(2026, 10, 18, 0, 0)},
+		// Either day field may match when both are restricted
+		{"0 12 1 * mon", at(2026, 10, 17, 0, 0), at(2026, 10, 19, 12, 0)},
+		{"0 12 1 * mon", at(2026, 10, 26, 12, 0), at(2026, 11, 1, 12, 0)},
+		{"0 0 29 2 *", at(2026, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+		{"0 0 30 2 *", at(2026, 3, 1, 0, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parseCron() unexpected error: %v", err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronSchedule_NextRepeatedHour(t *testing.T) {
+	dublin, err := time.LoadLocation("Europe/Dublin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	s, err := parseCron("30 1 * * *")
+	if err != nil {
+		t.Fatalf("parseCron() unexpected error: %v", err)
+	}
+
+	// Clocks go back from 02:00 to 01:00 on 25 October 2026, so 01:30 happens
+	// twice; the job should only run at the first
+	first := s.Next(time.Date(2026, 10, 25, 0, 0, 0, 0, dublin))
+	if want := time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC); !first.Equal(want) {
+		t.Fatalf("first run = %s, want %s", first, want)
+	}
+
+	second := s.Next(first)
+	if want := time.Date(2026, 10, 26, 1, 30, 0, 0, time.UTC); !second.Equal(want) {
+		t.Errorf("second run = %s, want %s", second, want)
+	}
+}
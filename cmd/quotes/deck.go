@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// useDeck holds the --deck flag value
+var useDeck bool
+
+// deckState is the persisted position in a shuffled collection
+type deckState struct {
+	// Collection is the CollectionHash the order was shuffled from
+	Collection string `json:"collection"`
+
+	// Order lists quote IDs in the order they are dealt
+	Order []string `json:"order"`
+
+	// Position is the index in Order of the next quote to deal
+	Position int `json:"position"`
+}
+
+// quotesStateDir returns the quotes directory under $XDG_STATE_HOME,
+// defaulting to ~/.local/state/quotes as the XDG base directory spec requires
+func quotesStateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "quotes"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "quotes"), nil
+}
+
+// deckPath returns the state file for the collection selected by the current
+// sources and filter flags, so each distinct collection keeps its own deck
+func deckPath() (string, error) {
+	dir, err := quotesStateDir()
+	if err != nil {
+		return "", err
+	}
+
+	sources, err := ResolveSources(quoteFiles)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s\x00%q|%t|%d|%d|%q|%q", strings.Join(sources, "\n"),
+		filterAuthor, filterExactAuthor, filterMinLength, filterMaxLength, filterMatch, filterTag)
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(dir, "deck-"+hex.EncodeToString(sum[:])[:16]+".json"), nil
+}
+
+// deal returns the next n quotes from the deck. The deck is reshuffled with
+// seed when it is exhausted or was shuffled from a different collection, or
+// when its position is out of range because the state file was damaged.
+func (d *deckState) deal(quotes []Quote, n int, seed int64) ([]Quote, error) {
+	byID := make(map[string]Quote, len(quotes))
+	for _, q := range quotes {
+		byID[q.ID] = q
+	}
+
+	if hash := CollectionHash(quotes); d.Collection != hash || d.Position < 0 || d.Position > len(d.Order) {
+		*d = deckState{Collection: hash}
+	}
+
+	dealt := make([]Quote, 0, n)
+	dealtIDs := make(map[string]bool, n)
+	for len(dealt) < n {
+		if d.Position >= len(d.Order) {
+			if err := d.shuffle(quotes, seed, dealtIDs); err != nil {
+				return nil, err
+			}
+		}
+
+		id := d.Order[d.Position]
+		dealt = append(dealt, byID[id])
+		dealtIDs[id] = true
+		d.Position++
+	}
+
+	return dealt, nil
+}
+
+// shuffle replaces the deck order with a fresh seeded permutation of quotes.
+// Quotes in last, those already dealt by the deal in progress, go to the end
+// of the new order, so a deal that runs over into a new pass doesn't repeat
+// them while any other quote is left.
+func (d *deckState) shuffle(quotes []Quote, seed int64, last map[string]bool) error {
+	shuffled, err := SelectQuotes(quotes, len(quotes), seed, false)
+	if err != nil {
+		return err
+	}
+
+	d.Order = make([]string, 0, len(shuffled))
+	for _, q := range shuffled {
+		if !last[q.ID] {
+			d.Order = append(d.Order, q.ID)
+		}
+	}
+	for _, q := range shuffled {
+		if last[q.ID] {
+			d.Order = append(d.Order, q.ID)
+		}
+	}
+	d.Position = 0
+	return nil
+}
+
+// DealFromDeck deals n quotes from the deck persisted at path, creating it if
+// needed. The state file is locked for the whole read-modify-write so
+// concurrent invocations each get different quotes.
+func DealFromDeck(path string, quotes []Quote, n int, seed int64) ([]Quote, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot lock deck: %w", err)
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var deck deckState
+	if len(data) > 0 && json.Unmarshal(data, &deck) != nil {
+		// A damaged deck is simply reshuffled
+		deck = deckState{}
+	}
+
+	dealt, err := deck.deal(quotes, n, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = json.Marshal(deck)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(0); err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return nil, err
+	}
+
+	return dealt, nil
+}
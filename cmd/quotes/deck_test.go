@@ -0,0 +1,208 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDeckState_DealsWholeCollectionBeforeRepeating(t *testing.T) {
+	quotes := withIDs(sampleQuotes)
+	var deck deckState
+
+	seen := make(map[string]bool)
+	for i := 0; i < len(quotes); i++ {
+		dealt, err := deck.deal(quotes, 1, 42)
+		if err != nil {
+			t.Fatalf("deal() unexpected error: %v", err)
+		}
+		if seen[dealt[0].ID] {
+			t.Fatalf("deal() repeated %q before the deck was exhausted", dealt[0].Text)
+		}
+		seen[dealt[0].ID] = true
+	}
+
+	// The next deal reshuffles and starts a new pass
+	if _, err := deck.deal(quotes, 1, 43); err != nil {
+		t.Fatalf("deal() unexpected error: %v", err)
+	}
+	if deck.Position != 1 || len(deck.Order) != len(quotes) {
+		t.Errorf("deck not reshuffled after exhaustion: %+v", deck)
+	}
+}
+
+func TestDeckState_ReshufflesWhenCollectionChanges(t *testing.T) {
+	quotes := withIDs(sampleQuotes)
+	var deck deckState
+
+	if _, err := deck.deal(quotes, 2, 42); err != nil {
+		t.Fatalf("deal() unexpected error: %v", err)
+	}
+
+	changed := withIDs(append(append([]Quote{}, sampleQuotes...), Quote{Text: "New", Author: "Someone"}))
+	dealt, err := deck.deal(changed, 1, 42)
+	if err != nil {
+		t.Fatalf("deal() unexpected error: %v", err)
+	}
+
+	if deck.Collection != CollectionHash(changed) {
+		t.Error("deck still tracks the old collection")
+	}
+	if len(deck.Order) != len(changed) || deck.Position != 1 {
+		t.Errorf("deck not reshuffled for the new collection: %+v", deck)
+	}
+	if dealt[0].Text == "" {
+		t.Error("deal() returned an empty quote after reshuffling")
+	}
+}
+
+func TestDeckState_DealAcrossPassesIsDistinct(t *testing.T) {
+	quotes := withIDs(sampleQuotes)[:3]
+
+	for seed := int64(0); seed < 200; seed++ {
+		var deck deckState
+		if _, err := deck.deal(quotes, 1, seed); err != nil {
+			t.Fatalf("deal() unexpected error: %v", err)
+		}
+
+		// Two cards remain, so the next deal of 2 fits; the one after runs
+		// into a new pass partway through
+		for round := 0; round < 3; round++ {
+			dealt, err := deck.deal(quotes, 2, seed)
+			if err != nil {
+				t.Fatalf("deal() unexpected error: %v", err)
+			}
+			if dealt[0].ID == dealt[1].ID {
+				t.Fatalf("seed %d round %d: deal() returned %q twice", seed, round, dealt[0].Text)
+			}
+		}
+	}
+}
+
+func TestDeckState_DamagedPosition(t *testing.T) {
+	quotes := withIDs(sampleQuotes)
+
+	for _, position := range []int{-1, len(quotes) + 1} {
+		deck := deckState{Collection: CollectionHash(quotes), Position: position}
+		for _, q := range quotes {
+			deck.Order = append(deck.Order, q.ID)
+		}
+
+		dealt, err := deck.deal(quotes, 1, 42)
+		if err != nil {
+			t.Fatalf("deal() unexpected error: %v", err)
+		}
+		if dealt[0].Text == "" || deck.Position != 1 {
+			t.Errorf("position %d: deck not reshuffled: %+v", position, deck)
+		}
+	}
+}
+
+func TestDealFromDeck_PersistsAcrossCalls(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "deck.json")
+	quotes := withIDs(sampleQuotes)
+
+	seen := make(map[string]bool)
+	for i := 0; i < len(quotes); i++ {
+		dealt, err := DealFromDeck(path, quotes, 1, int64(i))
+		if err != nil {
+			t.Fatalf("DealFromDeck() unexpected error: %v", err)
+		}
+		if seen[dealt[0].ID] {
+			t.Fatalf("DealFromDeck() repeated %q within one pass", dealt[0].Text)
+		}
+		seen[dealt[0].ID] = true
+	}
+}
+
+func TestDealFromDeck_ConcurrentCallers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deck.json")
+	quotes := withIDs(defaultQuotes)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]int)
+
+	for i := 0; i < len(quotes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dealt, err := DealFromDeck(path, quotes, 1, 7)
+			if err != nil {
+				t.Errorf("DealFromDeck() unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			seen[dealt[0].ID]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != len(quotes) {
+		t.Errorf("concurrent deals produced %d distinct quotes, want %d", len(seen), len(quotes))
+	}
+}
+
+func TestDealFromDeck_DamagedStateIsReshuffled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deck.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	if _, err := DealFromDeck(path, withIDs(sampleQuotes), 1, 1); err != nil {
+		t.Errorf("DealFromDeck() unexpected error: %v", err)
+	}
+}
+
+func TestQuotesStateDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	if got, _ := quotesStateDir(); got != filepath.Join(home, "state", "quotes") {
+		t.Errorf("quotesStateDir() = %q, want XDG_STATE_HOME/quotes", got)
+	}
+
+	t.Setenv("XDG_STATE_HOME", "")
+	if got, _ := quotesStateDir(); got != filepath.Join(home, ".local", "state", "quotes") {
+		t.Errorf("quotesStateDir() = %q, want ~/.local/state/quotes", got)
+	}
+}
+
+func TestDeckFlag(t *testing.T) {
+	home := isolateSources(t)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	seen := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		output, err := executeCommand(newRootCommand(), "--deck", "--author", "Steve Jobs", "--format", "json")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if i < 3 {
+			if seen[output] {
+				t.Fatalf("deck repeated a quote within one pass: %s", output)
+			}
+			seen[output] = true
+		}
+	}
+
+	// Filtering by a different author keeps a separate deck
+	entries, _ := os.ReadDir(filepath.Join(home, "state", "quotes"))
+	if _, err := executeCommand(newRootCommand(), "--deck", "--author", "Kent Beck"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	after, _ := os.ReadDir(filepath.Join(home, "state", "quotes"))
+	if len(after) <= len(entries) {
+		t.Error("a different filter should create a separate deck")
+	}
+
+	_, err := executeCommand(newRootCommand(), "--deck", "--author", "Steve Jobs", "--count", "4")
+	if err == nil || !strings.Contains(err.Error(), "not enough quotes") {
+		t.Errorf("expected not enough quotes error, got %v", err)
+	}
+}
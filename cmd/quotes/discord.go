@@ -0,0 +1,319 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// discordKeyEnv names the environment variable --public-key defaults to
+const discordKeyEnv = "DISCORD_PUBLIC_KEY"
+
+// discordMaxBody bounds the size of an interaction request
+const discordMaxBody = 64 * 1024
+
+// Discord's limits on embed text, in characters. An interaction response
+// breaking any of them is rejected as a whole.
+const (
+	// discordMaxTitle bounds both the title and the author name
+	discordMaxTitle       = 256
+	discordMaxDescription = 4096
+	discordMaxFieldValue  = 1024
+	discordMaxEmbed       = 6000
+)
+
+// discordEmbedColor is the accent colour of quote embeds (Discord blurple)
+const discordEmbedColor = 0x5865F2
+
+// Interaction and response types from the Discord API
+const (
+	discordPing               = 1
+	discordApplicationCommand = 2
+
+	discordPong                     = 1
+	discordChannelMessageWithSource = 4
+
+	// discordEphemeral marks a message only the invoking user can see
+	discordEphemeral = 1 << 6
+
+	// discordStringOption is the option type for string arguments
+	discordStringOption = 3
+)
+
+var (
+	discordListen       string
+	discordPublicKey    string
+	discordPrintCommand bool
+)
+
+// discordCommand is the /quote application command, as registered with
+// Discord
+var discordCommand = map[string]interface{}{
+	"name":        "quote",
+	"type":        1,
+	"description": "Get a random quote",
+	"options": []map[string]interface{}{
+		{"type": discordStringOption, "name": "author", "description": "Only quotes by a matching author", "required": false},
+		{"type": discordStringOption, "name": "tag", "description": "Only quotes with this tag", "required": false},
+	},
+}
+
+// newDiscordCommand creates the discord subcommand, which answers Discord
+// interactions
+func newDiscordCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "discord",
+		Short: "Answer a Discord /quote command",
+		Long: `Serve a Discord interactions endpoint at POST /discord/interactions.
+
+Set the application's Interactions Endpoint URL to it and pass the application's
+public key with --public-key or DISCORD_PUBLIC_KEY. Requests are checked against
+their Ed25519 signature and rejected if it doesn't verify, or if their
+X-Signature-Timestamp is more than five minutes from the server's clock, so
+captured requests can't be replayed.
+
+The /quote command takes optional author and tag options and replies with an
+embed of a random matching quote. Print the JSON to register the command with
+--print-command.
+
+SIGTERM or SIGINT shuts the server down gracefully, and SIGHUP reloads the
+collection.`,
+		Args: cobra.NoArgs,
+		RunE: runDiscord,
+	}
+
+	cmd.Flags().StringVar(&discordListen, "listen", "localhost:3000", "Address to listen on")
+	cmd.Flags().StringVar(&discordPublicKey, "public-key", os.Getenv(discordKeyEnv), "Application public key, in hex (default $"+discordKeyEnv+")")
+	cmd.Flags().BoolVar(&discordPrintCommand, "print-command", false, "Print the JSON that registers /quote with Discord and exit")
+
+	return cmd
+}
+
+// runDiscord prints the command registration or serves interactions until
+// SIGTERM or SIGINT
+func runDiscord(cmd *cobra.Command, args []string) error {
+	if discordPrintCommand {
+		data, err := json.MarshalIndent(discordCommand, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	key, err := parseDiscordKey(discordPublicKey)
+	if err != nil {
+		return err
+	}
+
+	store, err := newQuoteStore(loadFilteredQuotes)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", discordListen)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("answering Discord interactions with %d quotes on http://%s/discord/interactions", len(store.Collection().quotes), listener.Addr())
+	return serveUntilSignalled(newHTTPServer(newDiscordHandler(store, key)), listener, store)
+}
+
+// parseDiscordKey decodes a hex Ed25519 public key
+func parseDiscordKey(s string) (ed25519.PublicKey, error) {
+	if s == "" {
+		return nil, fmt.Errorf("--public-key or %s must be set to the application's public key", discordKeyEnv)
+	}
+
+	key, err := hex.DecodeString(s)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: must be %d bytes of hex", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// discordInteraction holds the parts of an interaction the server reads
+type discordInteraction struct {
+	Type int `json:"type"`
+	Data struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name  string          `json:"name"`
+			Value json.RawMessage `json:"value"`
+		} `json:"options"`
+	} `json:"data"`
+}
+
+// discordEmbed is a Discord message embed
+type discordEmbed struct {
+	Title       string              `json:"title,omitempty"`
+	URL         string              `json:"url,omitempty"`
+	Description string              `json:"description"`
+	Color       int                 `json:"color"`
+	Author      *discordEmbedName   `json:"author,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+	Footer      *discordEmbedName   `json:"footer,omitempty"`
+}
+
+// discordEmbedName is the author or footer of an embed
+type discordEmbedName struct {
+	Name string `json:"name,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// discordEmbedField is a titled value shown under an embed's description
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// newDiscordHandler answers interactions signed by the holder of key using
+// quotes from store
+func newDiscordHandler(store *quoteStore, key ed25519.PublicKey) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /discord/interactions", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, discordMaxBody))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := verifyDiscordRequest(key, r.Header, body, now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		var interaction discordInteraction
+		if err := json.Unmarshal(body, &interaction); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var response map[string]interface{}
+		switch interaction.Type {
+		case discordPing:
+			response = map[string]interface{}{"type": discordPong}
+		case discordApplicationCommand:
+			response = answerDiscordCommand(store.Collection(), interaction)
+		default:
+			http.Error(w, fmt.Sprintf("unsupported interaction type %d", interaction.Type), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	})
+	return mux
+}
+
+// verifyDiscordRequest checks the X-Signature-Ed25519 header, a signature of
+// the timestamp followed by the body, and rejects requests whose timestamp is
+// more than maxRequestSkew from now
+func verifyDiscordRequest(key ed25519.PublicKey, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Signature-Timestamp")
+	if err := checkRequestTime(timestamp, now); err != nil {
+		return err
+	}
+
+	signature, err := hex.DecodeString(header.Get("X-Signature-Ed25519"))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return errBadSignature
+	}
+
+	message := append([]byte(timestamp), body...)
+	if !ed25519.Verify(key, message, signature) {
+		return errBadSignature
+	}
+	return nil
+}
+
+// answerDiscordCommand replies to /quote with an embed of a random quote
+// matching its options. Problems are reported only to the invoking user.
+func answerDiscordCommand(c *collection, interaction discordInteraction) map[string]interface{} {
+	reply := func(data map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": discordChannelMessageWithSource, "data": data}
+	}
+
+	if interaction.Data.Name != discordCommand["name"] {
+		return reply(map[string]interface{}{"content": "unknown command: " + interaction.Data.Name, "flags": discordEphemeral})
+	}
+
+	var filter Filter
+	for _, option := range interaction.Data.Options {
+		var value string
+		json.Unmarshal(option.Value, &value)
+
+		switch option.Name {
+		case "author":
+			filter.Author = value
+		case "tag":
+			filter.Tag = value
+		}
+	}
+
+	q, err := randomMatching(c.quotes, filter)
+	if err != nil {
+		return reply(map[string]interface{}{"content": err.Error(), "flags": discordEphemeral})
+	}
+
+	return reply(map[string]interface{}{"embeds": []discordEmbed{quoteEmbed(q)}})
+}
+
+// quoteEmbed renders q as an embed: the text as the description, the author
+// above it, the source as a title linking to the URL, and the remaining
+// metadata as fields
+func quoteEmbed(q Quote) discordEmbed {
+	embed := discordEmbed{
+		Color:  discordEmbedColor,
+		Author: &discordEmbedName{Name: truncateText(discordMaxTitle, q.Author)},
+		Footer: &discordEmbedName{Text: "Quote " + q.ID},
+	}
+
+	// Discord rejects links other than http(s) ones
+	embed.Title = truncateText(discordMaxTitle, q.Source)
+	if isWebURL(q.URL) {
+		if embed.Title == "" {
+			embed.Title = "Read more"
+		}
+		embed.URL = q.URL
+	}
+
+	field := func(name, value string, inline bool) {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: name, Value: truncateText(discordMaxFieldValue, value), Inline: inline})
+	}
+	if q.Year != 0 {
+		field("Year", strconv.Itoa(q.Year), true)
+	}
+	if len(q.Tags) > 0 {
+		field("Tags", "`"+strings.Join(q.Tags, "`, `")+"`", true)
+	}
+	if q.Language != "" {
+		field("Language", q.Language, true)
+	}
+	if q.Notes != "" {
+		field("Notes", q.Notes, false)
+	}
+
+	// The description gets whatever the embed's total allows after the rest
+	used := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Author.Name) + utf8.RuneCountInString(embed.Footer.Text)
+	for _, f := range embed.Fields {
+		used += utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+	}
+	embed.Description = truncateText(min(discordMaxDescription, discordMaxEmbed-used), q.Text)
+
+	return embed
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newDiscordKeys returns a fixed key pair for signing test interactions
+func newDiscordKeys() (ed25519.PublicKey, ed25519.PrivateKey) {
+	private := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	return private.Public().(ed25519.PublicKey), private
+}
+
+// discordClock is the time signed test interactions are sent at
+var discordClock = time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+// discordRequest builds an interaction request signed at t
+func discordRequest(private ed25519.PrivateKey, body string, t time.Time) *http.Request {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	signature := ed25519.Sign(private, []byte(timestamp+body))
+
+	req := httptest.NewRequest(http.MethodPost, "/discord/interactions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(signature))
+	req.Header.Set("X-Signature-Timestamp", timestamp)
+	return req
+}
+
+func TestVerifyDiscordRequest(t *testing.T) {
+	public, private := newDiscordKeys()
+	body := `{"type":1}`
+
+	otherTimestamp := discordRequest(private, body, discordClock).Header
+	otherTimestamp.Set("X-Signature-Timestamp", strconv.FormatInt(discordClock.Unix()+1, 10))
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   error
+	}{
+		{name: "valid", header: discordRequest(private, body, discordClock).Header, body: body},
+		{name: "slightly early clock", header: discordRequest(private, body, discordClock.Add(4*time.Minute)).Header, body: body},
+		{name: "tampered body", header: discordRequest(private, body, discordClock).Header, body: `{"type":2}`, want: errBadSignature},
+		{name: "other timestamp", header: otherTimestamp, body: body, want: errBadSignature},
+		{name: "replayed", header: discordRequest(private, body, discordClock.Add(-6*time.Minute)).Header, body: body, want: errStaleRequest},
+		{name: "from the future", header: discordRequest(private, body, discordClock.Add(6*time.Minute)).Header, body: body, want: errStaleRequest},
+		{name: "unsigned", header: http.Header{}, body: body, want: errStaleRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyDiscordRequest(public, tt.header, []byte(tt.body), discordClock)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("verifyDiscordRequest() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscordHandler(t *testing.T) {
+	now = func() time.Time { return discordClock }
+	defer func() { now = time.Now }()
+
+	public, private := newDiscordKeys()
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return withIDs(defaultQuotes), nil
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+	handler := newDiscordHandler(store, public)
+
+	tests := []struct {
+		name        string
+		body        string
+		tamper      bool
+		wantStatus  int
+		wantContain string
+	}{
+		{name: "ping", body: `{"type":1}`, wantStatus: 200, wantContain: `{"type":1}`},
+		{name: "quote", body: `{"type":2,"data":{"name":"quote"}}`, wantStatus: 200, wantContain: `"embeds":[{`},
+		{name: "quote by author", body: `{"type":2,"data":{"name":"quote","options":[{"name":"author","type":3,"value":"dijkstra"}]}}`, wantStatus: 200, wantContain: `"author":{"name":"Edsger Dijkstra"}`},
+		{name: "no matches", body: `{"type":2,"data":{"name":"quote","options":[{"name":"tag","type":3,"value":"nothing"}]}}`, wantStatus: 200, wantContain: `"flags":64`},
+		{name: "unknown command", body: `{"type":2,"data":{"name":"joke"}}`, wantStatus: 200, wantContain: "unknown command: joke"},
+		{name: "unsupported type", body: `{"type":3}`, wantStatus: 400},
+		{name: "bad signature", body: `{"type":1}`, tamper: true, wantStatus: 401, wantContain: errBadSignature.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := discordRequest(private, tt.body, discordClock)
+			if tt.tamper {
+				req.Header.Set("X-Signature-Timestamp", strconv.FormatInt(discordClock.Unix()+1, 10))
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantContain) {
+				t.Errorf("body %s does not contain %s", rec.Body.String(), tt.wantContain)
+			}
+		})
+	}
+}
+
+func TestQuoteEmbed(t *testing.T) {
+	q := Quote{ID: "abc12345", Text: "Hello", Author: "World", URL: "https://example.com", Year: 2001, Tags: []string{"a", "b"}}
+	embed := quoteEmbed(q)
+
+	if embed.Title != "Read more" || embed.URL != q.URL {
+		t.Errorf("title/url = %q/%q, want a Read more link to %s", embed.Title, embed.URL, q.URL)
+	}
+	if embed.Footer.Text != "Quote abc12345" {
+		t.Errorf("footer = %q", embed.Footer.Text)
+	}
+
+	var names []string
+	for _, field := range embed.Fields {
+		names = append(names, field.Name+"="+field.Value)
+	}
+	if got := strings.Join(names, ";"); got != "Year=2001;Tags=`a`, `b`" {
+		t.Errorf("fields = %s", got)
+	}
+}
+
+func TestQuoteEmbed_Limits(t *testing.T) {
+	q := Quote{
+		ID:     "abc12345",
+		Text:   strings.Repeat("word ", 1000),
+		Author: strings.Repeat("A", 300),
+		Source: strings.Repeat("S", 300),
+		URL:    "javascript:alert(1)",
+		Notes:  strings.Repeat("n", 2000),
+		Tags:   []string{"a"},
+	}
+	embed := quoteEmbed(q)
+
+	count := utf8.RuneCountInString
+	if embed.URL != "" {
+		t.Errorf("url = %q, want no link for a non-http URL", embed.URL)
+	}
+	if count(embed.Title) != discordMaxTitle || !strings.HasSuffix(embed.Title, "…") {
+		t.Errorf("title has %d characters, want it cut to %d", count(embed.Title), discordMaxTitle)
+	}
+	if count(embed.Author.Name) != discordMaxTitle {
+		t.Errorf("author has %d characters, want %d", count(embed.Author.Name), discordMaxTitle)
+	}
+
+	total := count(embed.Title) + count(embed.Description) + count(embed.Author.Name) + count(embed.Footer.Text)
+	for _, field := range embed.Fields {
+		if count(field.Value) > discordMaxFieldValue {
+			t.Errorf("field %s has %d characters, want at most %d", field.Name, count(field.Value), discordMaxFieldValue)
+		}
+		total += count(field.Name) + count(field.Value)
+	}
+	if count(embed.Description) > discordMaxDescription || !strings.HasSuffix(embed.Description, "…") {
+		t.Errorf("description has %d characters, want it cut to at most %d", count(embed.Description), discordMaxDescription)
+	}
+	if total > discordMaxEmbed {
+		t.Errorf("embed has %d characters, want at most %d", total, discordMaxEmbed)
+	}
+
+	// A link that isn't http(s) gets neither a URL nor a Read more title
+	if embed := quoteEmbed(Quote{Text: "Hi", Author: "Me", URL: "ftp://example.com/x"}); embed.URL != "" || embed.Title != "" {
+		t.Errorf("title/url = %q/%q, want neither for an ftp link", embed.Title, embed.URL)
+	}
+}
+
+func TestParseDiscordKey(t *testing.T) {
+	public, _ := newDiscordKeys()
+
+	if _, err := parseDiscordKey(hex.EncodeToString(public)); err != nil {
+		t.Errorf("parseDiscordKey() unexpected error: %v", err)
+	}
+	for _, bad := range []string{"", "zz", "abcd"} {
+		if _, err := parseDiscordKey(bad); err == nil {
+			t.Errorf("parseDiscordKey(%q) expected error", bad)
+		}
+	}
+}
+
+func TestDiscordPrintCommand(t *testing.T) {
+	output, err := executeCommand(newRootCommand(), "discord", "--print-command")
+	if err != nil {
+		t.Fatalf("discord --print-command unexpected error: %v", err)
+	}
+
+	var command struct {
+		Name    string `json:"name"`
+		Options []struct {
+			Name string `json:"name"`
+			Type int    `json:"type"`
+		} `json:"options"`
+	}
+	if err := json.Unmarshal([]byte(output), &command); err != nil {
+		t.Fatalf("invalid JSON %q: %v", output, err)
+	}
+	if command.Name != "quote" || len(command.Options) != 2 || command.Options[0].Name != "author" {
+		t.Errorf("unexpected command %+v", command)
+	}
+}
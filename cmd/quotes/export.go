@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat   string
+	exportOutput   string
+	exportTitle    string
+	exportCompact  bool
+	exportPageSize string
+	exportSort     string
+)
+
+// pageSizes holds the --page-size choices, as width and height in points
+var pageSizes = map[string][2]float64{
+	"a4":     {595, 842},
+	"a5":     {420, 595},
+	"letter": {612, 792},
+}
+
+// Type sizes and spacing of booklets, in points
+const (
+	bookletLineSpacing     = 1.4
+	bookletTitleSize       = 28
+	bookletSubtitleSize    = 12
+	bookletQuoteSize       = 18
+	bookletMinQuoteSize    = 10
+	bookletAttributionSize = 10
+	bookletCompactSize     = 11
+	bookletCompactGap      = 18
+	bookletHeadingSize     = 16
+	bookletIndexSize       = 10
+	bookletFooterSize      = 9
+)
+
+// Shades of gray booklet text is set in, from 0 (black) to 1 (white)
+const (
+	bookletInk   = 0
+	bookletMuted = 0.4
+)
+
+// bookletAlign is how a line of booklet text sits between the margins
+type bookletAlign int
+
+const (
+	alignLeft bookletAlign = iota
+	alignCenter
+	alignRight
+)
+
+// booklet lays out a collection as a PDF, flowing lines down pages
+type booklet struct {
+	doc    *pdfDocument
+	margin float64
+	page   *pdfPage
+	// y is how far up the current page the next line starts, in points
+	y float64
+	// authorPages holds the pages each author's quotes start on
+	authorPages map[string][]int
+}
+
+// newExportCommand creates the export subcommand, which writes the
+// collection as a printable document
+func newExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the collection as a printable PDF booklet",
+		Long: `Export the whole collection, or the quotes matching the filter flags, as a
+printable PDF booklet: a title page, the quotes, one to a page or run together
+with --compact, and an index of authors, with page numbers throughout.
+
+The PDF is set in Helvetica, one of the standard fonts every PDF viewer and
+printer provides, so nothing needs embedding. Characters the font lacks, such
+as CJK text, print as '?'.`,
+		Args: cobra.NoArgs,
+		RunE: runExport,
+	}
+
+	cmd.Flags().StringVarP(&exportFormat, "format", "f", "pdf", "Export format: pdf")
+	cmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File to write the export to (default stdout)")
+	cmd.Flags().StringVar(&exportTitle, "title", "Quotes", "Title printed on the title page")
+	cmd.Flags().BoolVar(&exportCompact, "compact", false, "Run quotes together down each page instead of one per page")
+	cmd.Flags().StringVar(&exportPageSize, "page-size", "a5", "Page size: "+strings.Join(styleNames(pageSizes), "|"))
+	cmd.Flags().StringVar(&exportSort, "sort", "", "Sort by: author|length|id (default: collection order)")
+
+	return cmd
+}
+
+// runExport writes the filtered collection as a PDF booklet
+func runExport(cmd *cobra.Command, args []string) error {
+	if exportFormat != "pdf" {
+		return fmt.Errorf("invalid export format: %s (must be one of: pdf)", exportFormat)
+	}
+	size, ok := pageSizes[exportPageSize]
+	if !ok {
+		return fmt.Errorf("invalid page size: %s (must be one of: %s)", exportPageSize, strings.Join(styleNames(pageSizes), ", "))
+	}
+	if exportOutput == "" {
+		if _, isTerminal := terminalWidth(os.Stdout); isTerminal {
+			return fmt.Errorf("not writing a PDF to the terminal; use --output or redirect it")
+		}
+	}
+
+	quotes, err := loadFilteredQuotes()
+	if err != nil {
+		return err
+	}
+	if err := SortQuotes(quotes, exportSort, false); err != nil {
+		return err
+	}
+
+	data, err := renderBooklet(quotes, exportTitle, size[0], size[1], exportCompact).bytes()
+	if err != nil {
+		return err
+	}
+
+	if exportOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(exportOutput, data, 0644)
+}
+
+// renderBooklet lays out quotes as a booklet with pages of the given size
+func renderBooklet(quotes []Quote, title string, width, height float64, compact bool) *pdfDocument {
+	b := &booklet{
+		doc:         newPDFDocument(title, width, height),
+		margin:      width / 8,
+		authorPages: make(map[string][]int),
+	}
+
+	b.titlePage(title, quotes)
+	if compact {
+		b.newPage()
+		for _, q := range quotes {
+			b.compactQuote(q)
+		}
+	} else {
+		for _, q := range quotes {
+			b.quotePage(q)
+		}
+	}
+	b.index()
+	b.pageNumbers()
+
+	return b.doc
+}
+
+// newPage starts a page and moves to its top
+func (b *booklet) newPage() {
+	b.page = b.doc.addPage()
+	b.y = b.top()
+}
+
+// top returns how far up a page its first line starts
+func (b *booklet) top() float64 {
+	return b.doc.height - b.margin
+}
+
+// boxWidth returns the width between the margins
+func (b *booklet) boxWidth() float64 {
+	return b.doc.width - 2*b.margin
+}
+
+// line draws text as the next line, starting a page when the current one is
+// full, and returns its baseline
+func (b *booklet) line(font *pdfFont, size float64, text string, align bookletAlign, gray float64) float64 {
+	height := size * bookletLineSpacing
+	if b.y-height < b.margin {
+		b.newPage()
+	}
+	baseline := b.y - size
+	b.draw(font, size, text, align, gray, baseline)
+	b.y -= height
+	return baseline
+}
+
+// draw sets text on the current page at baseline
+func (b *booklet) draw(font *pdfFont, size float64, text string, align bookletAlign, gray, baseline float64) {
+	x := b.margin
+	switch align {
+	case alignCenter:
+		x = (b.doc.width - font.measure(text, size)) / 2
+	case alignRight:
+		x = b.doc.width - b.margin - font.measure(text, size)
+	}
+	b.page.text(font, size, x, baseline, gray, text)
+}
+
+// titlePage draws the title, and how many quotes and authors follow, a
+// little above the middle of a page of their own
+func (b *booklet) titlePage(title string, quotes []Quote) {
+	b.newPage()
+
+	authors := make(map[string]bool)
+	for _, q := range quotes {
+		authors[q.Author] = true
+	}
+
+	lines := helveticaBold.wrap(title, bookletTitleSize, b.boxWidth())
+	b.y = b.doc.height*0.6 + float64(len(lines))*bookletTitleSize*bookletLineSpacing/2
+	for _, line := range lines {
+		b.line(helveticaBold, bookletTitleSize, line, alignCenter, bookletInk)
+	}
+	b.y -= bookletSubtitleSize
+	summary := countNoun(len(quotes), "quote") + " by " + countNoun(len(authors), "author")
+	b.line(helvetica, bookletSubtitleSize, summary, alignCenter, bookletMuted)
+}
+
+// quotePage draws a quote vertically centred on a page of its own, at the
+// largest size down to bookletMinQuoteSize at which it fits
+func (b *booklet) quotePage(q Quote) {
+	b.newPage()
+
+	attributionLines := helvetica.wrap("— "+attribution(q, q.Source), bookletAttributionSize, b.boxWidth())
+	size := float64(bookletQuoteSize)
+	lines := helveticaOblique.wrap(q.Text, size, b.boxWidth())
+	boxHeight := b.top() - b.margin
+	for size > bookletMinQuoteSize && quoteHeight(lines, size, attributionLines, bookletAttributionSize) > boxHeight {
+		size--
+		lines = helveticaOblique.wrap(q.Text, size, b.boxWidth())
+	}
+
+	b.y -= max(boxHeight-quoteHeight(lines, size, attributionLines, bookletAttributionSize), 0) / 2
+	b.quote(q, lines, size, attributionLines, bookletAttributionSize)
+}
+
+// compactQuote draws a quote below the previous one, starting a page when it
+// doesn't fit on the current one
+func (b *booklet) compactQuote(q Quote) {
+	attributionSize := float64(bookletAttributionSize - 1)
+	attributionLines := helvetica.wrap("— "+attribution(q, q.Source), attributionSize, b.boxWidth())
+	lines := helveticaOblique.wrap(q.Text, bookletCompactSize, b.boxWidth())
+
+	if b.y < b.top() {
+		if b.y-bookletCompactGap-quoteHeight(lines, bookletCompactSize, attributionLines, attributionSize) < b.margin {
+			b.newPage()
+		} else {
+			b.y -= bookletCompactGap
+		}
+	}
+	b.quote(q, lines, bookletCompactSize, attributionLines, attributionSize)
+}
+
+// quote draws the wrapped text of a quote above its attribution, and records
+// the page it starts on for the index
+func (b *booklet) quote(q Quote, lines []string, size float64, attributionLines []string, attributionSize float64) {
+	if b.y-size*bookletLineSpacing < b.margin {
+		b.newPage()
+	}
+	page := len(b.doc.pages)
+	if pages := b.authorPages[q.Author]; len(pages) == 0 || pages[len(pages)-1] != page {
+		b.authorPages[q.Author] = append(pages, page)
+	}
+
+	for _, line := range lines {
+		b.line(helveticaOblique, size, line, alignLeft, bookletInk)
+	}
+	b.y -= attributionSize * bookletLineSpacing / 2
+	for _, line := range attributionLines {
+		b.line(helvetica, attributionSize, line, alignRight, bookletMuted)
+	}
+}
+
+// quoteHeight returns how tall quote draws the given lines
+func quoteHeight(lines []string, size float64, attributionLines []string, attributionSize float64) float64 {
+	text := float64(len(lines)) * size * bookletLineSpacing
+	gap := attributionSize * bookletLineSpacing / 2
+	return text + gap + float64(len(attributionLines))*attributionSize*bookletLineSpacing
+}
+
+// index draws the authors in alphabetical order, each with the pages their
+// quotes start on
+func (b *booklet) index() {
+	b.newPage()
+	b.line(helveticaBold, bookletHeadingSize, "Index of Authors", alignLeft, bookletInk)
+	b.y -= bookletIndexSize
+
+	authors := make([]string, 0, len(b.authorPages))
+	for author := range b.authorPages {
+		authors = append(authors, author)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		a, c := strings.ToLower(authors[i]), strings.ToLower(authors[j])
+		if a == c {
+			return authors[i] < authors[j]
+		}
+		return a < c
+	})
+
+	for _, author := range authors {
+		var numbers []string
+		for _, page := range b.authorPages[author] {
+			numbers = append(numbers, strconv.Itoa(page))
+		}
+		pages := strings.Join(numbers, ", ")
+
+		nameWidth := b.boxWidth() - helvetica.measure(pages, bookletIndexSize) - bookletIndexSize
+		for i, line := range helvetica.wrap(author, bookletIndexSize, nameWidth) {
+			baseline := b.line(helvetica, bookletIndexSize, line, alignLeft, bookletInk)
+			if i == 0 {
+				b.draw(helvetica, bookletIndexSize, pages, alignRight, bookletInk, baseline)
+			}
+		}
+	}
+}
+
+// pageNumbers draws a page number centred at the foot of every page but the
+// title page
+func (b *booklet) pageNumbers() {
+	for i, page := range b.doc.pages[1:] {
+		b.page = page
+		b.draw(helvetica, bookletFooterSize, strconv.Itoa(i+2), alignCenter, bookletMuted, b.margin/2)
+	}
+}
+
+// countNoun returns n followed by noun, made plural unless n is 1
+func countNoun(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
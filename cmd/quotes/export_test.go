@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRenderBooklet(t *testing.T) {
+	quotes := []Quote{
+		{Text: "Talk is cheap. Show me the code.", Author: "Linus Torvalds"},
+		{Text: "Simplicity is prerequisite for reliability.", Author: "Edsger Dijkstra"},
+		{Text: "Most good programmers do programming not because they expect to get paid, but because it is fun to program.", Author: "Linus Torvalds"},
+	}
+
+	t.Run("one quote per page", func(t *testing.T) {
+		doc := renderBooklet(quotes, "Offsite", 420, 595, false)
+
+		if len(doc.pages) != 5 {
+			t.Fatalf("renderBooklet() has %d pages, want title, 3 quotes and index", len(doc.pages))
+		}
+		pages := make([]string, len(doc.pages))
+		for i, page := range doc.pages {
+			pages[i] = page.content.String()
+		}
+
+		for _, want := range []string{"(Offsite)", "(3 quotes by 2 authors)"} {
+			if !strings.Contains(pages[0], want) {
+				t.Errorf("title page missing %s:\n%s", want, pages[0])
+			}
+		}
+		if strings.Contains(pages[0], "(1) Tj") {
+			t.Errorf("title page has a page number:\n%s", pages[0])
+		}
+		for i, want := range []string{"(Talk is cheap. Show me the code.)", "(Simplicity is prerequisite for", "(Most good programmers do"} {
+			if !strings.Contains(pages[i+1], want) {
+				t.Errorf("page %d missing %s:\n%s", i+2, want, pages[i+1])
+			}
+		}
+		if !strings.Contains(pages[1], "(\\227 Linus Torvalds)") {
+			t.Errorf("page 2 missing the attribution:\n%s", pages[1])
+		}
+
+		for _, want := range []string{"(Index of Authors)", "(Edsger Dijkstra)", "(3)", "(Linus Torvalds)", "(2, 4)", "(5) Tj"} {
+			if !strings.Contains(pages[4], want) {
+				t.Errorf("index missing %s:\n%s", want, pages[4])
+			}
+		}
+		if strings.Index(pages[4], "(Edsger Dijkstra)") > strings.Index(pages[4], "(Linus Torvalds)") {
+			t.Errorf("index is not in alphabetical order:\n%s", pages[4])
+		}
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		doc := renderBooklet(quotes, "Offsite", 420, 595, true)
+
+		if len(doc.pages) != 3 {
+			t.Fatalf("renderBooklet() has %d pages, want title, quotes and index", len(doc.pages))
+		}
+		index := doc.pages[2].content.String()
+		if !strings.Contains(index, "(Linus Torvalds)") || !strings.Contains(index, "(2) Tj") {
+			t.Errorf("index doesn't list page 2 once for both quotes:\n%s", index)
+		}
+		if strings.Contains(index, "(2, 2)") {
+			t.Errorf("index repeats a page:\n%s", index)
+		}
+	})
+
+	t.Run("compact flows onto new pages", func(t *testing.T) {
+		many := make([]Quote, 40)
+		for i := range many {
+			many[i] = quotes[i%len(quotes)]
+		}
+		doc := renderBooklet(many, "Offsite", 420, 595, true)
+
+		if len(doc.pages) < 5 {
+			t.Errorf("renderBooklet() has %d pages for 40 quotes, want them spread over several", len(doc.pages))
+		}
+		for i, page := range doc.pages[1:] {
+			if !strings.Contains(page.content.String(), "("+strconv.Itoa(i+2)+") Tj") {
+				t.Errorf("page %d has no page number", i+2)
+			}
+		}
+	})
+}
+
+func TestCountNoun(t *testing.T) {
+	tests := map[int]string{0: "0 quotes", 1: "1 quote", 2: "2 quotes"}
+	for n, want := range tests {
+		if got := countNoun(n, "quote"); got != want {
+			t.Errorf("countNoun(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestExportCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		args     []string
+		file     string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name:     "pdf to stdout",
+			args:     []string{"export", "--author", "dijkstra", "--title", "Dijkstra"},
+			contains: []string{"(Dijkstra)", "by 1 author)", "(Edsger Dijkstra)"},
+		},
+		{
+			name:     "file, compact and sorted",
+			args:     []string{"export", "--compact", "--sort", "length", "--page-size", "a4", "-o", filepath.Join(dir, "book.pdf")},
+			file:     filepath.Join(dir, "book.pdf"),
+			contains: []string{"(Index of Authors)"},
+		},
+		{
+			name:    "other formats",
+			args:    []string{"export", "--format", "json"},
+			wantErr: "invalid export format: json",
+		},
+		{
+			name:    "bad page size",
+			args:    []string{"export", "--page-size", "b5"},
+			wantErr: "invalid page size: b5 (must be one of: a4, a5, letter)",
+		},
+		{
+			name:    "bad sort",
+			args:    []string{"export", "--sort", "year"},
+			wantErr: "invalid sort key",
+		},
+		{
+			name:    "no matches",
+			args:    []string{"export", "--author", "nobody at all"},
+			wantErr: "no quotes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateSources(t)
+			output, err := executeCommand(newRootCommand(), tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data := []byte(output)
+			if tt.file != "" {
+				if output != "" {
+					t.Errorf("wrote %d bytes to stdout with --output", len(output))
+				}
+				if data, err = os.ReadFile(tt.file); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.HasPrefix(data, []byte("%PDF-")) {
+				t.Fatalf("output is not a PDF: %.20q", data)
+			}
+
+			content := strings.Join(pdfStreams(t, data), "")
+			for _, want := range tt.contains {
+				if !strings.Contains(content, want) {
+					t.Errorf("PDF text missing %s", want)
+				}
+			}
+		})
+	}
+}
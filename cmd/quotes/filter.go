@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrNoMatches is returned when filtering leaves no quotes to choose from
+var ErrNoMatches = errors.New("no quotes match the given filters")
+
+// Filter narrows a quote collection before selection.
+// Zero values disable the corresponding criterion.
+type Filter struct {
+	// Author matches a case-insensitive substring of the quote's author,
+	// or the whole author name (still ignoring case) when ExactAuthor is set
+	Author      string
+	ExactAuthor bool
+
+	// MinLength and MaxLength bound the quote text length in characters
+	MinLength int
+	MaxLength int
+
+	// Match is applied to the quote text
+	Match *regexp.Regexp
+
+	// Tag requires one of the quote's tags to equal it, ignoring case
+	Tag string
+}
+
+// Matches reports whether a quote satisfies every criterion of the filter
+func (f Filter) Matches(q Quote) bool {
+	if f.Author != "" {
+		if f.ExactAuthor {
+			if !strings.EqualFold(q.Author, f.Author) {
+				return false
+			}
+		} else if !strings.Contains(strings.ToLower(q.Author), strings.ToLower(f.Author)) {
+			return false
+		}
+	}
+
+	length := utf8.RuneCountInString(q.Text)
+	if f.MinLength > 0 && length < f.MinLength {
+		return false
+	}
+	if f.MaxLength > 0 && length > f.MaxLength {
+		return false
+	}
+
+	if f.Match != nil && !f.Match.MatchString(q.Text) {
+		return false
+	}
+
+	if f.Tag != "" && !hasTag(q, f.Tag) {
+		return false
+	}
+
+	return true
+}
+
+// hasTag reports whether q carries tag, ignoring case
+func hasTag(q Quote, tag string) bool {
+	for _, t := range q.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterQuotes returns the quotes that satisfy the filter, preserving order.
+// Returns ErrNoMatches if nothing is left.
+func FilterQuotes(quotes []Quote, f Filter) ([]Quote, error) {
+	result := make([]Quote, 0, len(quotes))
+	for _, q := range quotes {
+		if f.Matches(q) {
+			result = append(result, q)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNoMatches
+	}
+
+	return result, nil
+}
+
+var (
+	filterAuthor      string
+	filterExactAuthor bool
+	filterMinLength   int
+	filterMaxLength   int
+	filterMatch       string
+	filterTag         string
+)
+
+// addFilterFlags registers the collection filter flags on cmd and all of its
+// subcommands
+func addFilterFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&filterAuthor, "author", "", "Only quotes whose author contains this text (case-insensitive)")
+	flags.BoolVar(&filterExactAuthor, "exact-author", false, "Require --author to match the whole author name")
+	flags.IntVar(&filterMinLength, "min-length", 0, "Only quotes with at least this many characters")
+	flags.IntVar(&filterMaxLength, "max-length", 0, "Only quotes with at most this many characters")
+	flags.StringVar(&filterMatch, "match", "", "Only quotes whose text matches this regular expression")
+	flags.StringVar(&filterTag, "tag", "", "Only quotes with this tag (case-insensitive)")
+}
+
+// buildFilter validates the filter flags and converts them into a Filter
+func buildFilter() (Filter, error) {
+	f := Filter{
+		Author:      filterAuthor,
+		ExactAuthor: filterExactAuthor,
+		MinLength:   filterMinLength,
+		MaxLength:   filterMaxLength,
+		Tag:         filterTag,
+	}
+
+	if f.MinLength < 0 || f.MaxLength < 0 {
+		return Filter{}, fmt.Errorf("length bounds must not be negative")
+	}
+
+	if f.MaxLength > 0 && f.MinLength > f.MaxLength {
+		return Filter{}, fmt.Errorf("--min-length %d exceeds --max-length %d", f.MinLength, f.MaxLength)
+	}
+
+	if filterMatch != "" {
+		re, err := regexp.Compile(filterMatch)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid --match pattern: %w", err)
+		}
+		f.Match = re
+	}
+
+	return f, nil
+}
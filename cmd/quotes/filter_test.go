@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFilter_Tag(t *testing.T) {
+	q := Quote{Text: "Make it work", Author: "Kent Beck", Tags: []string{"Craft", "simplicity"}}
+
+	tests := []struct {
+		tag  string
+		want bool
+	}{
+		{tag: "craft", want: true},
+		{tag: "SIMPLICITY", want: true},
+		{tag: "simple", want: false},
+		{tag: "", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := (Filter{Tag: tt.tag}).Matches(q); got != tt.want {
+			t.Errorf("Filter{Tag: %q}.Matches() = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestFilterQuotes(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		want    []string // expected quote texts, in order
+		wantErr error
+	}{
+		{
+			name:   "empty filter keeps everything",
+			filter: Filter{},
+			want: []string{
+				"Be the change you wish to see in the world",
+				"Code is poetry",
+				"The only way to do great work is to love what you do",
+				"Innovation distinguishes between a leader and a follower",
+				"Stay hungry, stay foolish",
+			},
+		},
+		{
+			name:   "author substring ignores case",
+			filter: Filter{Author: "jobs"},
+			want: []string{
+				"The only way to do great work is to love what you do",
+				"Innovation distinguishes between a leader and a follower",
+				"Stay hungry, stay foolish",
+			},
+		},
+		{
+			name:    "exact author rejects substring",
+			filter:  Filter{Author: "Jobs", ExactAuthor: true},
+			wantErr: ErrNoMatches,
+		},
+		{
+			name:   "exact author ignores case",
+			filter: Filter{Author: "gandhi", ExactAuthor: true},
+			want:   []string{"Be the change you wish to see in the world"},
+		},
+		{
+			name:   "max length",
+			filter: Filter{MaxLength: 14},
+			want:   []string{"Code is poetry"},
+		},
+		{
+			name:   "min length",
+			filter: Filter{MinLength: 53},
+			want:   []string{"Innovation distinguishes between a leader and a follower"},
+		},
+		{
+			name:   "length bounds are inclusive",
+			filter: Filter{MinLength: 14, MaxLength: 14},
+			want:   []string{"Code is poetry"},
+		},
+		{
+			name:   "regex match",
+			filter: Filter{Match: regexp.MustCompile(`(?i)^stay`)},
+			want:   []string{"Stay hungry, stay foolish"},
+		},
+		{
+			name:   "criteria combine",
+			filter: Filter{Author: "Steve", MaxLength: 30},
+			want:   []string{"Stay hungry, stay foolish"},
+		},
+		{
+			name:    "no matches",
+			filter:  Filter{Author: "Dijkstra"},
+			wantErr: ErrNoMatches,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterQuotes(sampleQuotes, tt.filter)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("FilterQuotes() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("FilterQuotes() unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("FilterQuotes() returned %d quotes, want %d", len(got), len(tt.want))
+			}
+
+			for i := range got {
+				if got[i].Text != tt.want[i] {
+					t.Errorf("FilterQuotes()[%d] = %q, want %q", i, got[i].Text, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFilter_LengthCountsCharacters(t *testing.T) {
+	q := Quote{Text: "It's not a bug – it's", Author: "Anonymous"}
+
+	if !(Filter{MaxLength: 21}).Matches(q) {
+		t.Error("Filter counted bytes instead of characters")
+	}
+}
+
+func TestQuotesCommand_FilterFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantErr   string
+		wantMatch string
+	}{
+		{
+			name:      "author",
+			args:      []string{"--author", "dijkstra", "--count", "3"},
+			wantMatch: "Edsger Dijkstra",
+		},
+		{
+			name:      "exact author",
+			args:      []string{"--author", "Kent Beck", "--exact-author"},
+			wantMatch: "Kent Beck",
+		},
+		{
+			name:      "match",
+			args:      []string{"--match", "^Talk is cheap"},
+			wantMatch: "Linus Torvalds",
+		},
+		{
+			name:    "no matches",
+			args:    []string{"--author", "Nobody In Particular"},
+			wantErr: "no quotes match",
+		},
+		{
+			name:    "invalid regex",
+			args:    []string{"--match", "(unclosed"},
+			wantErr: "invalid --match pattern",
+		},
+		{
+			name:    "inverted length bounds",
+			args:    []string{"--min-length", "50", "--max-length", "10"},
+			wantErr: "exceeds --max-length",
+		},
+		{
+			name:    "filtered collection smaller than count",
+			args:    []string{"--author", "Kernighan", "--count", "10"},
+			wantErr: "not enough quotes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newRootCommand()
+			output, err := executeCommand(cmd, tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(output, tt.wantMatch) {
+				t.Errorf("output %q does not contain %q", output, tt.wantMatch)
+			}
+		})
+	}
+}
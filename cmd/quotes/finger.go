@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fingerHost      string
+	fingerPort      int
+	fingerRateLimit int
+)
+
+// newFingerCommand creates the serve-finger subcommand, an RFC 1288 finger
+// server
+func newFingerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve-finger",
+		Short: "Serve quotes over the RFC 1288 finger protocol",
+		Long: `Serve the collection over the finger protocol (RFC 1288).
+
+Queries:
+  finger @host                  list the queries and authors
+  finger quote@host             a random quote
+  finger today@host             the quote of the day (UTC)
+  finger author/Dijkstra@host   a random quote by a matching author
+
+Forwarding queries (user@host1@host2) are refused, as RFC 1288 recommends.
+
+SIGTERM or SIGINT stops the server, and SIGHUP reloads the collection.`,
+		Args: cobra.NoArgs,
+		RunE: runFinger,
+	}
+
+	cmd.Flags().StringVar(&fingerHost, "host", "", "Address to listen on (default all interfaces)")
+	cmd.Flags().IntVar(&fingerPort, "port", 79, "Port to listen on; ports below 1024 usually need root")
+	cmd.Flags().IntVar(&fingerRateLimit, "rate-limit", 10, "Queries per minute each client address may make (0 for no limit)")
+
+	return cmd
+}
+
+// runFinger serves finger queries until SIGTERM or SIGINT
+func runFinger(cmd *cobra.Command, args []string) error {
+	if fingerPort < 0 || fingerPort > 65535 {
+		return fmt.Errorf("--port must be 0-65535, got %d", fingerPort)
+	}
+	if fingerRateLimit < 0 {
+		return fmt.Errorf("--rate-limit must not be negative")
+	}
+
+	store, err := newQuoteStore(loadFilteredQuotes)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(fingerHost, strconv.Itoa(fingerPort)))
+	if err != nil {
+		return listenError(err)
+	}
+	defer listener.Close()
+
+	limiter := newRateLimiter(fingerRateLimit, time.Minute)
+	handle := func(query string) string {
+		return fingerReply(store.Collection(), query)
+	}
+
+	log.Printf("serving finger for %d quotes on %s", len(store.Collection().quotes), listener.Addr())
+	return runUntilSignalled(store, func() error {
+		return serveLineRequests(listener, limiter, handle)
+	})
+}
+
+// fingerReply answers a finger query line, with CRLF line endings
+func fingerReply(c *collection, query string) string {
+	// The verbose switch changes nothing here; quotes are always in full
+	query = strings.TrimSpace(query)
+	if query == "/W" || strings.HasPrefix(query, "/W ") {
+		query = strings.TrimSpace(strings.TrimPrefix(query, "/W"))
+	}
+
+	var reply string
+	switch {
+	case strings.Contains(query, "@"):
+		reply = "finger forwarding is not supported\n"
+	case query == "":
+		reply = fingerIndex(c)
+	case query == "quote":
+		reply = fingerQuote(c.quotes)
+	case query == "today":
+		q, err := QuoteOfThePeriod(c.quotes, now().UTC(), "day")
+		if err != nil {
+			reply = err.Error() + "\n"
+		} else {
+			reply = FormatText([]Quote{q})
+		}
+	case strings.HasPrefix(query, "author/"):
+		quotes, err := FilterQuotes(c.quotes, Filter{Author: strings.TrimPrefix(query, "author/")})
+		if err != nil {
+			reply = err.Error() + "\n"
+		} else {
+			reply = fingerQuote(quotes)
+		}
+	default:
+		reply = fmt.Sprintf("no such user: %s\ntry quote, today or author/<name>\n", query)
+	}
+
+	return strings.ReplaceAll(reply, "\n", "\r\n")
+}
+
+// fingerQuote formats one random quote from quotes
+func fingerQuote(quotes []Quote) string {
+	q, err := SelectRandom(quotes, time.Now().UnixNano())
+	if err != nil {
+		return err.Error() + "\n"
+	}
+	return FormatText([]Quote{q})
+}
+
+// fingerIndex lists the queries the server answers and the authors in the
+// collection
+func fingerIndex(c *collection) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d quotes. Try:\n", len(c.quotes))
+	b.WriteString("  finger quote@host\n")
+	b.WriteString("  finger today@host\n")
+	b.WriteString("  finger author/<name>@host\n")
+	b.WriteString("\nAuthors:\n")
+	for _, author := range authorCounts(c.quotes) {
+		fmt.Fprintf(&b, "  %-40s %d\n", author.name, author.count)
+	}
+	return b.String()
+}
+
+// authorCount is the number of quotes in a collection by one author
+type authorCount struct {
+	name  string
+	count int
+}
+
+// authorCounts returns every author in quotes with their number of quotes,
+// sorted by name
+func authorCounts(quotes []Quote) []authorCount {
+	counts := make(map[string]int)
+	for _, q := range quotes {
+		counts[q.Author]++
+	}
+
+	authors := make([]authorCount, 0, len(counts))
+	for name, count := range counts {
+		authors = append(authors, authorCount{name: name, count: count})
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		return strings.ToLower(authors[i].name) < strings.ToLower(authors[j].name)
+	})
+	return authors
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerReply(t *testing.T) {
+	c := newCollection(withIDs(defaultQuotes))
+
+	tests := []struct {
+		name        string
+		query       string
+		wantContain string
+	}{
+		{name: "index", query: "", wantContain: "Authors:"},
+		{name: "index lists authors", query: "", wantContain: "Edsger Dijkstra"},
+		{name: "verbose index", query: "/W", wantContain: "Authors:"},
+		{name: "quote", query: "quote", wantContain: "   - "},
+		{name: "verbose quote", query: "/W quote", wantContain: "   - "},
+		{name: "today", query: "today", wantContain: "   - "},
+		{name: "author", query: "author/dijkstra", wantContain: "   - Edsger Dijkstra"},
+		{name: "unknown author", query: "author/nobody", wantContain: "no quotes match"},
+		{name: "forwarding", query: "quote@elsewhere", wantContain: "forwarding is not supported"},
+		{name: "unknown user", query: "root", wantContain: "no such user: root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fingerReply(c, tt.query)
+			if !strings.Contains(got, tt.wantContain) {
+				t.Errorf("fingerReply(%q) = %q, want it to contain %q", tt.query, got, tt.wantContain)
+			}
+			if strings.Contains(strings.ReplaceAll(got, "\r\n", ""), "\n") {
+				t.Errorf("fingerReply(%q) has bare LF line endings", tt.query)
+			}
+		})
+	}
+}
+
+func TestAuthorCounts(t *testing.T) {
+	quotes := []Quote{
+		{Text: "a", Author: "beck"},
+		{Text: "b", Author: "Alan Kay"},
+		{Text: "c", Author: "beck"},
+	}
+
+	got := authorCounts(quotes)
+	want := []authorCount{{name: "Alan Kay", count: 1}, {name: "beck", count: 2}}
+	if len(got) != len(want) {
+		t.Fatalf("authorCounts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("authorCounts()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// glyphRows renders text in the bitmap font as rows of '#' and '.'
+func glyphRows(text string) []string {
+	rows := make([]string, glyphHeight)
+	for _, r := range text {
+		for y, bits := range glyphFor(r) {
+			for x := glyphWidth - 1; x >= 0; x-- {
+				if bits&(1<<x) != 0 {
+					rows[y] += "#"
+				} else {
+					rows[y] += "."
+				}
+			}
+			rows[y] += "."
+		}
+	}
+	return rows
+}
+
+func TestGlyphFor(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hi", []string{
+			"#...#...#...",
+			"#...#.......",
+			"#...#..##...",
+			"#####...#...",
+			"#...#...#...",
+			"#...#...#...",
+			"#...#..###..",
+		}},
+		// Typographic punctuation and accented letters fall back to ASCII
+		{"—é", glyphRows("-e")},
+		{"“’", glyphRows(`"'`)},
+		// Anything else is drawn as a box
+		{"日", []string{"#####.", "#...#.", "#...#.", "#...#.", "#...#.", "#...#.", "#####."}},
+	}
+
+	for _, tt := range tests {
+		got := glyphRows(tt.text)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("glyphRows(%q) =\n%s\nwant\n%s", tt.text, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
@@ -3,12 +3,16 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"html"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
 // FormatText formats quotes as plain text with author attribution.
 // For a single quote, outputs: "Text\n   - Author\n"
 // For multiple quotes, outputs numbered list: "1. Text\n   - Author\n"
+// Optional metadata (tags, language, URL, notes) follows on indented lines.
 func FormatText(quotes []Quote) string {
 	var result strings.Builder
 
@@ -16,14 +20,18 @@ func FormatText(quotes []Quote) string {
 		if len(quotes) > 1 {
 			fmt.Fprintf(&result, "%d. ", i+1)
 		}
-		fmt.Fprintf(&result, "%s\n   - %s\n", q.Text, q.Author)
+		fmt.Fprintf(&result, "%s\n   - %s\n", q.Text, attribution(q, q.Source))
+		for _, line := range metadataLines(q) {
+			fmt.Fprintf(&result, "     %s\n", line)
+		}
 	}
 
 	return result.String()
 }
 
 // FormatJSON formats quotes as indented JSON array.
-// Returns a JSON array of objects with "Text" and "Author" fields.
+// Returns a JSON array of objects with "text" and "author" fields, plus any
+// metadata fields that are set.
 func FormatJSON(quotes []Quote) string {
 	// Handle nil slice by treating it as empty array
 	if quotes == nil {
@@ -42,12 +50,153 @@ func FormatJSON(quotes []Quote) string {
 
 // FormatMarkdown formats quotes as markdown quote blocks.
 // Each quote is formatted as: "> Text\n\n— Author\n\n"
+// A source is italicised in the attribution, and remaining metadata follows
+// as its own paragraph.
 func FormatMarkdown(quotes []Quote) string {
 	var result strings.Builder
 
 	for _, q := range quotes {
-		fmt.Fprintf(&result, "> %s\n\n— %s\n\n", q.Text, q.Author)
+		source := q.Source
+		if source != "" {
+			source = "*" + source + "*"
+		}
+		fmt.Fprintf(&result, "> %s\n\n— %s\n\n", q.Text, attribution(q, source))
+
+		var meta []string
+		if len(q.Tags) > 0 {
+			meta = append(meta, "Tags: `"+strings.Join(q.Tags, "`, `")+"`")
+		}
+		if q.Language != "" {
+			meta = append(meta, "Language: "+q.Language)
+		}
+		if q.URL != "" {
+			meta = append(meta, "<"+q.URL+">")
+		}
+		if len(meta) > 0 {
+			fmt.Fprintf(&result, "%s\n\n", strings.Join(meta, " · "))
+		}
+		if q.Notes != "" {
+			fmt.Fprintf(&result, "%s\n\n", q.Notes)
+		}
+	}
+
+	return result.String()
+}
+
+// FormatHTML formats quotes as HTML fragments, one per quote:
+// "<figure><blockquote><p>Text</p></blockquote><figcaption>— Author</figcaption></figure>".
+// The source is a <cite> in the caption, the URL becomes the blockquote's
+// cite and a link, the language its lang, and tags and notes follow as
+// paragraphs. All quote fields are escaped.
+func FormatHTML(quotes []Quote) string {
+	var result strings.Builder
+
+	for _, q := range quotes {
+		result.WriteString(`<figure class="quote"`)
+		if q.ID != "" {
+			fmt.Fprintf(&result, ` id="quote-%s"`, html.EscapeString(q.ID))
+		}
+		result.WriteString(">\n<blockquote")
+		if isWebURL(q.URL) {
+			fmt.Fprintf(&result, ` cite="%s"`, html.EscapeString(q.URL))
+		}
+		if q.Language != "" {
+			fmt.Fprintf(&result, ` lang="%s"`, html.EscapeString(q.Language))
+		}
+		fmt.Fprintf(&result, ">\n<p>%s</p>\n</blockquote>\n", strings.ReplaceAll(html.EscapeString(q.Text), "\n", "<br>\n"))
+
+		escaped := q
+		escaped.Author = html.EscapeString(q.Author)
+		source := q.Source
+		if source != "" {
+			source = "<cite>" + html.EscapeString(source) + "</cite>"
+		}
+		fmt.Fprintf(&result, "<figcaption>&mdash; %s</figcaption>\n", attribution(escaped, source))
+
+		var meta []string
+		if len(q.Tags) > 0 {
+			tags := make([]string, len(q.Tags))
+			for i, tag := range q.Tags {
+				tags[i] = `<span class="tag">` + html.EscapeString(tag) + "</span>"
+			}
+			meta = append(meta, "Tags: "+strings.Join(tags, ", "))
+		}
+		if isWebURL(q.URL) {
+			link := html.EscapeString(q.URL)
+			meta = append(meta, `<a href="`+link+`">`+link+"</a>")
+		} else if q.URL != "" {
+			meta = append(meta, html.EscapeString(q.URL))
+		}
+		if len(meta) > 0 {
+			fmt.Fprintf(&result, "<p class=\"quote-meta\">%s</p>\n", strings.Join(meta, " &middot; "))
+		}
+		if q.Notes != "" {
+			fmt.Fprintf(&result, "<p class=\"quote-notes\">%s</p>\n", html.EscapeString(q.Notes))
+		}
+
+		result.WriteString("</figure>\n")
 	}
 
 	return result.String()
 }
+
+// isWebURL reports whether s is an http or https URL, the only kinds HTML
+// output links to
+func isWebURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")) && u.Host != ""
+}
+
+// formatQuotes renders quotes in the named output format, which must already
+// have been validated with isValidFormat
+func formatQuotes(format string, quotes []Quote) string {
+	switch format {
+	case "json":
+		return FormatJSON(quotes)
+	case "markdown":
+		return FormatMarkdown(quotes)
+	case "html":
+		return FormatHTML(quotes)
+	case "say":
+		return FormatSay(quotes, drawCharacter(characters["cow"], true), defaultSayWidth)
+	default:
+		return FormatText(quotes)
+	}
+}
+
+// attribution builds the attribution line for a quote: the author, followed by
+// the (already formatted) source and the year when present.
+func attribution(q Quote, source string) string {
+	parts := make([]string, 0, 2)
+	if q.Author != "" || source == "" {
+		parts = append(parts, q.Author)
+	}
+	if source != "" {
+		parts = append(parts, source)
+	}
+
+	result := strings.Join(parts, ", ")
+	if q.Year != 0 {
+		result += " (" + strconv.Itoa(q.Year) + ")"
+	}
+	return result
+}
+
+// metadataLines returns "Label: value" lines for the optional metadata fields
+// that plain-text output renders below the attribution.
+func metadataLines(q Quote) []string {
+	var lines []string
+	if len(q.Tags) > 0 {
+		lines = append(lines, "Tags: "+strings.Join(q.Tags, ", "))
+	}
+	if q.Language != "" {
+		lines = append(lines, "Language: "+q.Language)
+	}
+	if q.URL != "" {
+		lines = append(lines, "URL: "+q.URL)
+	}
+	if q.Notes != "" {
+		lines = append(lines, "Notes: "+q.Notes)
+	}
+	return lines
+}
@@ -54,6 +54,29 @@ func TestFormatText(t *testing.T) {
 			quotes: []Quote{{Text: "Quote with \"quotes\" and \nnewline", Author: "Test"}},
 			want:   "Quote with \"quotes\" and \nnewline\n   - Test\n",
 		},
+		{
+			name:   "quote with source and year",
+			quotes: []Quote{{Text: "Debug", Author: "Kernighan", Source: "Elements", Year: 1974}},
+			want:   "Debug\n   - Kernighan, Elements (1974)\n",
+		},
+		{
+			name:   "quote with source and no author",
+			quotes: []Quote{{Text: "Debug", Source: "Elements"}},
+			want:   "Debug\n   - Elements\n",
+		},
+		{
+			name: "quote with all metadata",
+			quotes: []Quote{{
+				ID:       "abc",
+				Text:     "Debug",
+				Author:   "Kernighan",
+				Tags:     []string{"debugging", "humor"},
+				Language: "en",
+				URL:      "https://example.com",
+				Notes:    "Classic",
+			}},
+			want: "Debug\n   - Kernighan\n     Tags: debugging, humor\n     Language: en\n     URL: https://example.com\n     Notes: Classic\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -131,6 +154,50 @@ func TestFormatJSON(t *testing.T) {
 	}
 }
 
+func TestFormatJSON_Metadata(t *testing.T) {
+	t.Run("optional fields omitted when empty", func(t *testing.T) {
+		got := FormatJSON([]Quote{{Text: "Be", Author: "Gandhi"}})
+
+		var parsed []map[string]interface{}
+		if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+			t.Fatalf("FormatJSON() produced invalid JSON: %v", err)
+		}
+
+		if len(parsed[0]) != 2 {
+			t.Errorf("FormatJSON() = %s, want only text and author keys", got)
+		}
+		if parsed[0]["text"] != "Be" || parsed[0]["author"] != "Gandhi" {
+			t.Errorf("FormatJSON() = %s, want lowercase text and author keys", got)
+		}
+	})
+
+	t.Run("metadata round-trips", func(t *testing.T) {
+		want := Quote{
+			ID:       "abc",
+			Text:     "Debug",
+			Author:   "Kernighan",
+			Tags:     []string{"debugging"},
+			Source:   "Elements",
+			Year:     1974,
+			URL:      "https://example.com",
+			Language: "en",
+			Notes:    "Classic",
+		}
+
+		var parsed []Quote
+		if err := json.Unmarshal([]byte(FormatJSON([]Quote{want})), &parsed); err != nil {
+			t.Fatalf("FormatJSON() produced invalid JSON: %v", err)
+		}
+
+		got := parsed[0]
+		if got.ID != want.ID || got.Source != want.Source || got.Year != want.Year ||
+			got.URL != want.URL || got.Language != want.Language || got.Notes != want.Notes ||
+			len(got.Tags) != 1 || got.Tags[0] != "debugging" {
+			t.Errorf("FormatJSON() round-trip = %+v, want %+v", got, want)
+		}
+	})
+}
+
 func TestFormatMarkdown(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -179,6 +246,23 @@ func TestFormatMarkdown(t *testing.T) {
 			quotes: []Quote{{Text: "Quote with \"quotes\"", Author: "Test"}},
 			want:   "> Quote with \"quotes\"\n\n— Test\n\n",
 		},
+		{
+			name:   "quote with source and year",
+			quotes: []Quote{{Text: "Debug", Author: "Kernighan", Source: "Elements", Year: 1974}},
+			want:   "> Debug\n\n— Kernighan, *Elements* (1974)\n\n",
+		},
+		{
+			name: "quote with all metadata",
+			quotes: []Quote{{
+				Text:     "Debug",
+				Author:   "Kernighan",
+				Tags:     []string{"debugging", "humor"},
+				Language: "en",
+				URL:      "https://example.com",
+				Notes:    "Classic",
+			}},
+			want: "> Debug\n\n— Kernighan\n\nTags: `debugging`, `humor` · Language: en · <https://example.com>\n\nClassic\n\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -201,6 +285,65 @@ func TestFormatMarkdown(t *testing.T) {
 	}
 }
 
+func TestFormatHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		quotes []Quote
+		want   string
+	}{
+		{
+			name:   "single quote",
+			quotes: []Quote{{Text: "Be", Author: "Gandhi"}},
+			want:   "<figure class=\"quote\">\n<blockquote>\n<p>Be</p>\n</blockquote>\n<figcaption>&mdash; Gandhi</figcaption>\n</figure>\n",
+		},
+		{
+			name:   "escapes text and attribution",
+			quotes: []Quote{{Text: "Use <b> & \"quotes\"", Author: "A & B", Source: "<Talk>", Year: 1999}},
+			want:   "<figure class=\"quote\">\n<blockquote>\n<p>Use &lt;b&gt; &amp; &#34;quotes&#34;</p>\n</blockquote>\n<figcaption>&mdash; A &amp; B, <cite>&lt;Talk&gt;</cite> (1999)</figcaption>\n</figure>\n",
+		},
+		{
+			name:   "line breaks",
+			quotes: []Quote{{Text: "One\nTwo", Author: "Poet"}},
+			want:   "<figure class=\"quote\">\n<blockquote>\n<p>One<br>\nTwo</p>\n</blockquote>\n<figcaption>&mdash; Poet</figcaption>\n</figure>\n",
+		},
+		{
+			name: "quote with all metadata",
+			quotes: []Quote{{
+				ID:       "k1",
+				Text:     "Debug",
+				Author:   "Kernighan",
+				Tags:     []string{"debugging", "humor"},
+				Language: "en",
+				URL:      "https://example.com/?a=1&b=2",
+				Notes:    "Classic",
+			}},
+			want: "<figure class=\"quote\" id=\"quote-k1\">\n<blockquote cite=\"https://example.com/?a=1&amp;b=2\" lang=\"en\">\n<p>Debug</p>\n</blockquote>\n" +
+				"<figcaption>&mdash; Kernighan</figcaption>\n" +
+				"<p class=\"quote-meta\">Tags: <span class=\"tag\">debugging</span>, <span class=\"tag\">humor</span> &middot; <a href=\"https://example.com/?a=1&amp;b=2\">https://example.com/?a=1&amp;b=2</a></p>\n" +
+				"<p class=\"quote-notes\">Classic</p>\n</figure>\n",
+		},
+		{
+			name:   "only web URLs are linked",
+			quotes: []Quote{{Text: "Hi", Author: "X", URL: "javascript:alert(1)"}},
+			want:   "<figure class=\"quote\">\n<blockquote>\n<p>Hi</p>\n</blockquote>\n<figcaption>&mdash; X</figcaption>\n<p class=\"quote-meta\">javascript:alert(1)</p>\n</figure>\n",
+		},
+		{
+			name:   "empty quotes slice",
+			quotes: []Quote{},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatHTML(tt.quotes)
+			if got != tt.want {
+				t.Errorf("FormatHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestFormatters_NilInput(t *testing.T) {
 	// Test that formatters handle nil slices gracefully
 	t.Run("FormatText with nil", func(t *testing.T) {
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// gopherTitleLength bounds the quote text shown in menu entries
+const gopherTitleLength = 60
+
+var (
+	gopherHost      string
+	gopherPort      int
+	gopherHostname  string
+	gopherRateLimit int
+)
+
+// newGopherCommand creates the serve-gopher subcommand, an RFC 1436 Gopher
+// server
+func newGopherCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve-gopher",
+		Short: "Serve quotes over the RFC 1436 Gopher protocol",
+		Long: `Serve the collection as a Gopher (RFC 1436) site.
+
+The root menu links to a random quote, the quote of the day (UTC), a full-text
+search and a menu of authors, each leading to that author's quotes.
+
+Menus link back to this server using --hostname and the port it listens on,
+so set --hostname to the name clients reach it by.
+
+SIGTERM or SIGINT stops the server, and SIGHUP reloads the collection.`,
+		Args: cobra.NoArgs,
+		RunE: runGopher,
+	}
+
+	cmd.Flags().StringVar(&gopherHost, "host", "", "Address to listen on (default all interfaces)")
+	cmd.Flags().IntVar(&gopherPort, "port", 70, "Port to listen on; ports below 1024 usually need root")
+	cmd.Flags().StringVar(&gopherHostname, "hostname", "localhost", "Host name menus tell clients to connect to")
+	cmd.Flags().IntVar(&gopherRateLimit, "rate-limit", 60, "Requests per minute each client address may make (0 for no limit)")
+
+	return cmd
+}
+
+// runGopher serves Gopher requests until SIGTERM or SIGINT
+func runGopher(cmd *cobra.Command, args []string) error {
+	if gopherPort < 0 || gopherPort > 65535 {
+		return fmt.Errorf("--port must be 0-65535, got %d", gopherPort)
+	}
+	if gopherRateLimit < 0 {
+		return fmt.Errorf("--rate-limit must not be negative")
+	}
+
+	store, err := newQuoteStore(loadFilteredQuotes)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(gopherHost, strconv.Itoa(gopherPort)))
+	if err != nil {
+		return listenError(err)
+	}
+	defer listener.Close()
+
+	site := gopherSite{hostname: gopherHostname, port: listener.Addr().(*net.TCPAddr).Port}
+	limiter := newRateLimiter(gopherRateLimit, time.Minute)
+	handle := func(selector string) string {
+		return site.reply(store.Collection(), selector)
+	}
+
+	log.Printf("serving gopher for %d quotes on %s", len(store.Collection().quotes), listener.Addr())
+	return runUntilSignalled(store, func() error {
+		return serveLineRequests(listener, limiter, handle)
+	})
+}
+
+// gopherSite builds the menus and documents of the Gopher site. Menu links
+// point at hostname and port.
+type gopherSite struct {
+	hostname string
+	port     int
+}
+
+// reply answers a selector line, which for searches carries the query after
+// a tab
+func (s gopherSite) reply(c *collection, request string) string {
+	selector, query, _ := strings.Cut(request, "\t")
+
+	switch {
+	case selector == "" || selector == "/":
+		return s.rootMenu(c)
+	case selector == "/random":
+		q, err := SelectRandom(c.quotes, time.Now().UnixNano())
+		if err != nil {
+			return s.errorMenu(err.Error())
+		}
+		return gopherText(FormatText([]Quote{q}))
+	case selector == "/today":
+		q, err := QuoteOfThePeriod(c.quotes, now().UTC(), "day")
+		if err != nil {
+			return s.errorMenu(err.Error())
+		}
+		return gopherText(FormatText([]Quote{q}))
+	case selector == "/authors":
+		return s.authorsMenu(c)
+	case strings.HasPrefix(selector, "/authors/"):
+		return s.authorMenu(c, strings.TrimPrefix(selector, "/authors/"))
+	case strings.HasPrefix(selector, "/quotes/"):
+		q, ok := c.byID[strings.TrimPrefix(selector, "/quotes/")]
+		if !ok {
+			return s.errorMenu("no such quote")
+		}
+		return gopherText(FormatText([]Quote{q}))
+	case selector == "/search":
+		return s.searchMenu(c, query)
+	default:
+		return s.errorMenu("no such selector: " + selector)
+	}
+}
+
+// rootMenu links to everything the site offers
+func (s gopherSite) rootMenu(c *collection) string {
+	var b strings.Builder
+	s.info(&b, "Quotes")
+	s.info(&b, fmt.Sprintf("%d quotes by %d authors", len(c.quotes), len(authorCounts(c.quotes))))
+	s.info(&b, "")
+	s.item(&b, '0', "Random quote", "/random")
+	s.item(&b, '0', "Quote of the day", "/today")
+	s.item(&b, '7', "Search quotes", "/search")
+	s.item(&b, '1', "Browse by author", "/authors")
+	b.WriteString(".\r\n")
+	return b.String()
+}
+
+// authorsMenu links to a menu for each author
+func (s gopherSite) authorsMenu(c *collection) string {
+	var b strings.Builder
+	for _, author := range authorCounts(c.quotes) {
+		s.item(&b, '1', fmt.Sprintf("%s (%d)", author.name, author.count), "/authors/"+author.name)
+	}
+	b.WriteString(".\r\n")
+	return b.String()
+}
+
+// authorMenu links to each quote by the named author
+func (s gopherSite) authorMenu(c *collection, author string) string {
+	quotes, err := FilterQuotes(c.quotes, Filter{Author: author, ExactAuthor: true})
+	if err != nil {
+		return s.errorMenu(err.Error())
+	}
+
+	var b strings.Builder
+	s.info(&b, author)
+	s.info(&b, "")
+	for _, q := range quotes {
+		s.item(&b, '0', gopherTitle(q.Text), "/quotes/"+q.ID)
+	}
+	b.WriteString(".\r\n")
+	return b.String()
+}
+
+// searchMenu links to the quotes matching query, best match first
+func (s gopherSite) searchMenu(c *collection, query string) string {
+	results, err := c.index.Search(query, true)
+	if err != nil {
+		return s.errorMenu(err.Error())
+	}
+
+	var b strings.Builder
+	s.info(&b, fmt.Sprintf("%d results for %s", len(results), query))
+	s.info(&b, "")
+	for _, result := range results {
+		s.item(&b, '0', gopherTitle(result.Text)+" - "+result.Author, "/quotes/"+result.ID)
+	}
+	b.WriteString(".\r\n")
+	return b.String()
+}
+
+// errorMenu reports message as a menu holding a single error item
+func (s gopherSite) errorMenu(message string) string {
+	return fmt.Sprintf("3%s\t\terror.host\t1\r\n.\r\n", gopherField(message))
+}
+
+// item writes a menu entry linking to selector on this site
+func (s gopherSite) item(b *strings.Builder, kind byte, title, selector string) {
+	fmt.Fprintf(b, "%c%s\t%s\t%s\t%d\r\n", kind, gopherField(title), gopherField(selector), s.hostname, s.port)
+}
+
+// info writes an informational menu line, which clients show as plain text
+func (s gopherSite) info(b *strings.Builder, text string) {
+	fmt.Fprintf(b, "i%s\t\terror.host\t1\r\n", gopherField(text))
+}
+
+// gopherField removes the tabs and line breaks that would corrupt a menu
+// line
+func gopherField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+// gopherTitle shortens quote text for a menu entry
+func gopherTitle(text string) string {
+	runes := []rune(text)
+	if len(runes) <= gopherTitleLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:gopherTitleLength-3])) + "..."
+}
+
+// gopherText encodes text as a Gopher text document: CRLF line endings,
+// leading periods doubled and a lone period to finish
+func gopherText(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if strings.HasPrefix(line, ".") {
+			line = "." + line
+		}
+		b.WriteString(line + "\r\n")
+	}
+	b.WriteString(".\r\n")
+	return b.String()
+}
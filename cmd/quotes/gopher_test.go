@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGopherSite(t *testing.T) {
+	quotes := withIDs(defaultQuotes)
+	c := newCollection(quotes)
+	site := gopherSite{hostname: "quotes.example", port: 7070}
+
+	dijkstra, _ := FilterQuotes(quotes, Filter{Author: "Edsger Dijkstra", ExactAuthor: true})
+
+	tests := []struct {
+		name        string
+		request     string
+		wantContain string
+	}{
+		{name: "root", request: "", wantContain: "1Browse by author\t/authors\tquotes.example\t7070\r\n"},
+		{name: "root slash", request: "/", wantContain: "7Search quotes\t/search\tquotes.example\t7070\r\n"},
+		{name: "authors", request: "/authors", wantContain: "1Edsger Dijkstra ("},
+		{name: "author", request: "/authors/Edsger Dijkstra", wantContain: "0" + gopherTitle(dijkstra[0].Text)},
+		{name: "unknown author", request: "/authors/Nobody", wantContain: "3no quotes match"},
+		{name: "quote", request: "/quotes/" + quotes[0].ID, wantContain: quotes[0].Text + "\r\n"},
+		{name: "unknown quote", request: "/quotes/ffffffff", wantContain: "3no such quote"},
+		{name: "random", request: "/random", wantContain: "   - "},
+		{name: "today", request: "/today", wantContain: "   - "},
+		{name: "search", request: "/search\tdebugging", wantContain: "results for debugging"},
+		{name: "empty search", request: "/search", wantContain: "3"},
+		{name: "unknown selector", request: "/nope", wantContain: "3no such selector: /nope\t\terror.host\t1\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := site.reply(c, tt.request)
+			if !strings.Contains(got, tt.wantContain) {
+				t.Errorf("reply(%q) = %q, want it to contain %q", tt.request, got, tt.wantContain)
+			}
+			if !strings.HasSuffix(got, "\r\n.\r\n") && got != ".\r\n" {
+				t.Errorf("reply(%q) does not end with a lone period", tt.request)
+			}
+		})
+	}
+}
+
+func TestGopherText(t *testing.T) {
+	got := gopherText("first\n.dotted\n")
+	want := "first\r\n..dotted\r\n.\r\n"
+	if got != want {
+		t.Errorf("gopherText() = %q, want %q", got, want)
+	}
+}
+
+func TestGopherTitle(t *testing.T) {
+	short := "Short quote"
+	if got := gopherTitle(short); got != short {
+		t.Errorf("gopherTitle(%q) = %q, want it unchanged", short, got)
+	}
+
+	long := strings.Repeat("word ", 20)
+	got := gopherTitle(long)
+	if len([]rune(got)) > gopherTitleLength || !strings.HasSuffix(got, "...") {
+		t.Errorf("gopherTitle() = %q, want at most %d characters ending in ...", got, gopherTitleLength)
+	}
+}
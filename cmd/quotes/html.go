@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	htmlStandalone bool
+	htmlTheme      string
+)
+
+// htmlBaseCSS lays out quotes on a standalone page. Themes only set the
+// custom properties it uses.
+const htmlBaseCSS = `body {
+  margin: 0;
+  background: var(--background);
+  color: var(--text);
+  font-family: var(--font);
+  line-height: 1.5;
+}
+main {
+  max-width: 42rem;
+  margin: 0 auto;
+  padding: 2rem 1rem;
+}
+figure.quote {
+  margin: 0 0 1.5rem;
+  padding: 1.25rem 1.5rem;
+  background: var(--card);
+  border-left: 4px solid var(--accent);
+  border-radius: 4px;
+}
+figure.quote blockquote {
+  margin: 0;
+  font-family: var(--quote-font);
+  font-size: 1.25rem;
+}
+figure.quote blockquote p {
+  margin: 0;
+}
+figure.quote figcaption {
+  margin-top: 0.75rem;
+  color: var(--muted);
+}
+.quote-meta, .quote-notes, .quote-score {
+  margin: 0.5rem 0 0;
+  font-size: 0.875rem;
+  color: var(--muted);
+}
+.tag {
+  padding: 0 0.3rem;
+  border-radius: 3px;
+  background: var(--background);
+}
+table.quotes {
+  border-collapse: collapse;
+  width: 100%;
+}
+table.quotes th, table.quotes td {
+  padding: 0.4rem 0.6rem;
+  border-bottom: 1px solid var(--muted);
+  text-align: left;
+  vertical-align: top;
+}
+a {
+  color: var(--accent);
+}
+`
+
+// htmlThemes holds the custom properties of each --theme
+var htmlThemes = map[string]string{
+	"light": `:root {
+  --background: #f6f6f3;
+  --card: #ffffff;
+  --text: #1f2328;
+  --muted: #656d76;
+  --accent: #3b5bdb;
+  --font: system-ui, -apple-system, "Segoe UI", Roboto, sans-serif;
+  --quote-font: var(--font);
+}
+`,
+	"dark": `:root {
+  color-scheme: dark;
+  --background: #0d1117;
+  --card: #161b22;
+  --text: #e6edf3;
+  --muted: #8d96a0;
+  --accent: #7aa2f7;
+  --font: system-ui, -apple-system, "Segoe UI", Roboto, sans-serif;
+  --quote-font: var(--font);
+}
+`,
+	"serif": `:root {
+  --background: #f8f4ea;
+  --card: #fffdf7;
+  --text: #2b2620;
+  --muted: #75695a;
+  --accent: #9a3412;
+  --font: Georgia, "Times New Roman", serif;
+  --quote-font: "Iowan Old Style", Palatino, Georgia, serif;
+}
+figure.quote blockquote {
+  font-style: italic;
+}
+`,
+}
+
+// addHTMLFlags registers --standalone and --theme on a command that prints
+// quotes with --format
+func addHTMLFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&htmlStandalone, "standalone", false, "With --format html, print a complete page with embedded CSS")
+	cmd.Flags().StringVar(&htmlTheme, "theme", "light", "Style of --standalone pages: "+strings.Join(htmlThemeNames(), "|"))
+}
+
+// checkHTMLFlags validates --standalone and --theme against the format
+func checkHTMLFlags(format string) error {
+	if _, ok := htmlThemes[htmlTheme]; !ok {
+		return fmt.Errorf("invalid theme: %s (must be one of: %s)", htmlTheme, strings.Join(htmlThemeNames(), ", "))
+	}
+	if htmlStandalone && format != "html" {
+		return fmt.Errorf("--standalone needs --format html")
+	}
+	return nil
+}
+
+// asDocument wraps HTML output in a standalone page when --standalone is
+// set, and returns any other output unchanged
+func asDocument(format, output string) string {
+	if format != "html" || !htmlStandalone {
+		return output
+	}
+	return HTMLPage(output, htmlTheme)
+}
+
+// HTMLPage wraps an HTML fragment in a complete page styled with the named
+// theme, which must be one of htmlThemes
+func HTMLPage(body, theme string) string {
+	var result strings.Builder
+
+	result.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	result.WriteString("<meta charset=\"utf-8\">\n")
+	result.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	result.WriteString("<title>Quotes</title>\n")
+	fmt.Fprintf(&result, "<style>\n%s%s</style>\n", htmlThemes[theme], htmlBaseCSS)
+	result.WriteString("</head>\n<body>\n<main>\n")
+	result.WriteString(body)
+	result.WriteString("</main>\n</body>\n</html>\n")
+
+	return result.String()
+}
+
+// htmlThemeNames returns the theme names in alphabetical order
+func htmlThemeNames() []string {
+	names := make([]string, 0, len(htmlThemes))
+	for name := range htmlThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLPage(t *testing.T) {
+	for _, theme := range htmlThemeNames() {
+		t.Run(theme, func(t *testing.T) {
+			got := HTMLPage(FormatHTML([]Quote{{Text: "Be", Author: "Gandhi"}}), theme)
+
+			if !strings.HasPrefix(got, "<!DOCTYPE html>\n") || !strings.HasSuffix(got, "</html>\n") {
+				t.Errorf("HTMLPage() is not a complete document:\n%s", got)
+			}
+			for _, want := range []string{`<meta charset="utf-8">`, htmlThemes[theme], "<main>\n<figure class=\"quote\">"} {
+				if !strings.Contains(got, want) {
+					t.Errorf("HTMLPage() missing %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantErr  string
+		contains []string
+	}{
+		{
+			name:     "fragment",
+			args:     []string{"--format", "html", "--seed", "1"},
+			contains: []string{"<figure class=\"quote\"", "<figcaption>&mdash; "},
+		},
+		{
+			name:     "standalone dark page",
+			args:     []string{"--format", "html", "--standalone", "--theme", "dark", "--seed", "1"},
+			contains: []string{"<!DOCTYPE html>", "color-scheme: dark", "<figure class=\"quote\""},
+		},
+		{
+			name:     "today",
+			args:     []string{"today", "--format", "html", "--standalone", "--theme", "serif"},
+			contains: []string{"<!DOCTYPE html>", "font-style: italic"},
+		},
+		{
+			name:     "list fields",
+			args:     []string{"list", "--format", "html", "--fields", "id,author", "--limit", "2"},
+			contains: []string{"<table class=\"quotes\">", "<th>author</th>"},
+		},
+		{
+			name:     "search scores",
+			args:     []string{"search", "code", "--format", "html", "--score", "--limit", "1"},
+			contains: []string{"<p class=\"quote-score\">Score: "},
+		},
+		{
+			name:    "standalone needs html",
+			args:    []string{"--standalone"},
+			wantErr: "--standalone needs --format html",
+		},
+		{
+			name:    "unknown theme",
+			args:    []string{"--format", "html", "--standalone", "--theme", "neon"},
+			wantErr: "invalid theme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateSources(t)
+			output, err := executeCommand(newRootCommand(), tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// quoteFields lists the field names accepted by --fields, in display order
+var quoteFields = []string{"id", "text", "author", "tags", "source", "year", "url", "language", "notes"}
+
+var (
+	listSort    string
+	listReverse bool
+	listLimit   int
+	listOffset  int
+	listFields  []string
+)
+
+// newListCommand creates the list subcommand, which prints the whole active
+// collection instead of a random pick
+func newListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the loaded quote collection",
+		Long:  "Print every quote in the active collection, optionally filtered, sorted and paged",
+		Args:  cobra.NoArgs,
+		RunE:  runList,
+	}
+
+	addOutputFlags(cmd)
+	cmd.Flags().StringVar(&listSort, "sort", "", "Sort by: author|length|id (default: collection order)")
+	cmd.Flags().BoolVar(&listReverse, "reverse", false, "Reverse the sort order")
+	cmd.Flags().IntVar(&listLimit, "limit", 0, "Maximum number of quotes to print (0 for all)")
+	cmd.Flags().IntVar(&listOffset, "offset", 0, "Number of quotes to skip before printing")
+	cmd.Flags().StringSliceVar(&listFields, "fields", nil, "Comma-separated fields to print: "+strings.Join(quoteFields, ","))
+
+	return cmd
+}
+
+// runList prints the filtered, sorted and paged collection
+func runList(cmd *cobra.Command, args []string) error {
+	out, err := newOutput()
+	if err != nil {
+		return err
+	}
+	if out.isTemplate() && len(listFields) > 0 {
+		return fmt.Errorf("--fields cannot be used with a template")
+	}
+	if format == "say" && len(listFields) > 0 {
+		return fmt.Errorf("--fields cannot be used with --format say")
+	}
+
+	if listLimit < 0 || listOffset < 0 {
+		return fmt.Errorf("--limit and --offset must not be negative")
+	}
+
+	for _, field := range listFields {
+		if !isValidField(field) {
+			return fmt.Errorf("invalid field: %s (must be one of: %s)", field, strings.Join(quoteFields, ", "))
+		}
+	}
+
+	quotes, err := loadFilteredQuotes()
+	if err != nil {
+		return err
+	}
+
+	if err := SortQuotes(quotes, listSort, listReverse); err != nil {
+		return err
+	}
+
+	quotes = pageQuotes(quotes, listOffset, listLimit)
+
+	if len(listFields) > 0 {
+		fmt.Print(asDocument(format, FormatFields(quotes, listFields, format)))
+		return nil
+	}
+
+	result, err := out.render(quotes)
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
+
+// SortQuotes sorts quotes in place by the given key (author, length or id).
+// An empty key keeps collection order. Ties keep their original relative order.
+func SortQuotes(quotes []Quote, key string, reverse bool) error {
+	var less func(a, b Quote) bool
+	switch key {
+	case "":
+		if reverse {
+			for i, j := 0, len(quotes)-1; i < j; i, j = i+1, j-1 {
+				quotes[i], quotes[j] = quotes[j], quotes[i]
+			}
+		}
+		return nil
+	case "author":
+		less = func(a, b Quote) bool { return strings.ToLower(a.Author) < strings.ToLower(b.Author) }
+	case "length":
+		less = func(a, b Quote) bool { return utf8.RuneCountInString(a.Text) < utf8.RuneCountInString(b.Text) }
+	case "id":
+		less = func(a, b Quote) bool { return a.ID < b.ID }
+	default:
+		return fmt.Errorf("invalid sort key: %s (must be one of: author, length, id)", key)
+	}
+
+	sort.SliceStable(quotes, func(i, j int) bool {
+		if reverse {
+			return less(quotes[j], quotes[i])
+		}
+		return less(quotes[i], quotes[j])
+	})
+
+	return nil
+}
+
+// pageQuotes returns the window of quotes selected by offset and limit.
+// A limit of 0 means no limit.
+func pageQuotes(quotes []Quote, offset, limit int) []Quote {
+	if offset >= len(quotes) {
+		return []Quote{}
+	}
+	quotes = quotes[offset:]
+
+	if limit > 0 && limit < len(quotes) {
+		quotes = quotes[:limit]
+	}
+	return quotes
+}
+
+// isValidField checks if the provided field name is valid
+func isValidField(field string) bool {
+	return contains(quoteFields, field)
+}
+
+// fieldValue returns the JSON value of a named quote field
+func fieldValue(q Quote, field string) interface{} {
+	switch field {
+	case "id":
+		return q.ID
+	case "text":
+		return q.Text
+	case "author":
+		return q.Author
+	case "tags":
+		if q.Tags == nil {
+			return []string{}
+		}
+		return q.Tags
+	case "source":
+		return q.Source
+	case "year":
+		return q.Year
+	case "url":
+		return q.URL
+	case "language":
+		return q.Language
+	default:
+		return q.Notes
+	}
+}
+
+// fieldString returns the display value of a named quote field
+func fieldString(q Quote, field string) string {
+	switch field {
+	case "tags":
+		return strings.Join(q.Tags, ", ")
+	case "year":
+		if q.Year == 0 {
+			return ""
+		}
+		return strconv.Itoa(q.Year)
+	default:
+		return fieldValue(q, field).(string)
+	}
+}
+
+// FormatFields renders only the selected fields of each quote.
+// Text output is tab-separated with one quote per line, JSON output is an
+// array of objects with the fields in the requested order, and markdown and
+// HTML output are tables.
+func FormatFields(quotes []Quote, fields []string, format string) string {
+	switch format {
+	case "json":
+		return formatFieldsJSON(quotes, fields)
+	case "markdown":
+		return formatFieldsMarkdown(quotes, fields)
+	case "html":
+		return formatFieldsHTML(quotes, fields)
+	default:
+		return formatFieldsText(quotes, fields)
+	}
+}
+
+func formatFieldsText(quotes []Quote, fields []string) string {
+	var result strings.Builder
+
+	for _, q := range quotes {
+		values := make([]string, len(fields))
+		for i, field := range fields {
+			// Keep each record on a single line
+			values[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(fieldString(q, field))
+		}
+		fmt.Fprintf(&result, "%s\n", strings.Join(values, "\t"))
+	}
+
+	return result.String()
+}
+
+func formatFieldsJSON(quotes []Quote, fields []string) string {
+	// Build objects by hand so keys keep the order given in --fields
+	var compact bytes.Buffer
+	compact.WriteByte('[')
+	for i, q := range quotes {
+		if i > 0 {
+			compact.WriteByte(',')
+		}
+		compact.WriteByte('{')
+		for j, field := range fields {
+			if j > 0 {
+				compact.WriteByte(',')
+			}
+			key, _ := json.Marshal(field)
+			value, _ := json.Marshal(fieldValue(q, field))
+			compact.Write(key)
+			compact.WriteByte(':')
+			compact.Write(value)
+		}
+		compact.WriteByte('}')
+	}
+	compact.WriteByte(']')
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, compact.Bytes(), "", "  "); err != nil {
+		return "[]"
+	}
+	return indented.String()
+}
+
+func formatFieldsMarkdown(quotes []Quote, fields []string) string {
+	var result strings.Builder
+
+	separators := make([]string, len(fields))
+	for i := range fields {
+		separators[i] = "---"
+	}
+	fmt.Fprintf(&result, "| %s |\n", strings.Join(fields, " | "))
+	fmt.Fprintf(&result, "| %s |\n", strings.Join(separators, " | "))
+
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	for _, q := range quotes {
+		values := make([]string, len(fields))
+		for i, field := range fields {
+			values[i] = escape.Replace(fieldString(q, field))
+		}
+		fmt.Fprintf(&result, "| %s |\n", strings.Join(values, " | "))
+	}
+
+	return result.String()
+}
+
+func formatFieldsHTML(quotes []Quote, fields []string) string {
+	var result strings.Builder
+
+	result.WriteString("<table class=\"quotes\">\n<thead>\n<tr>")
+	for _, field := range fields {
+		fmt.Fprintf(&result, "<th>%s</th>", field)
+	}
+	result.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	for _, q := range quotes {
+		result.WriteString("<tr>")
+		for _, field := range fields {
+			fmt.Fprintf(&result, "<td>%s</td>", html.EscapeString(fieldString(q, field)))
+		}
+		result.WriteString("</tr>\n")
+	}
+
+	result.WriteString("</tbody>\n</table>\n")
+	return result.String()
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSortQuotes(t *testing.T) {
+	quotes := []Quote{
+		{ID: "c", Text: "Medium text", Author: "bravo"},
+		{ID: "a", Text: "Longest text here", Author: "Alpha"},
+		{ID: "b", Text: "Short", Author: "charlie"},
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		reverse bool
+		want    []string // expected IDs
+		wantErr bool
+	}{
+		{name: "collection order", key: "", want: []string{"c", "a", "b"}},
+		{name: "collection order reversed", key: "", reverse: true, want: []string{"b", "a", "c"}},
+		{name: "author ignores case", key: "author", want: []string{"a", "c", "b"}},
+		{name: "length", key: "length", want: []string{"b", "c", "a"}},
+		{name: "length reversed", key: "length", reverse: true, want: []string{"a", "c", "b"}},
+		{name: "id", key: "id", want: []string{"a", "b", "c"}},
+		{name: "invalid key", key: "year", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := append([]Quote(nil), quotes...)
+			err := SortQuotes(got, tt.key, tt.reverse)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SortQuotes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for i, id := range tt.want {
+				if got[i].ID != id {
+					t.Errorf("SortQuotes()[%d] = %s, want %s", i, got[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestPageQuotes(t *testing.T) {
+	tests := []struct {
+		name          string
+		offset, limit int
+		want          int
+	}{
+		{name: "no paging", offset: 0, limit: 0, want: 5},
+		{name: "limit", offset: 0, limit: 2, want: 2},
+		{name: "offset", offset: 3, limit: 0, want: 2},
+		{name: "offset and limit", offset: 1, limit: 3, want: 3},
+		{name: "limit past end", offset: 4, limit: 10, want: 1},
+		{name: "offset past end", offset: 10, limit: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pageQuotes(sampleQuotes, tt.offset, tt.limit)
+			if len(got) != tt.want {
+				t.Errorf("pageQuotes() returned %d quotes, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFields(t *testing.T) {
+	quotes := []Quote{
+		{ID: "abc", Text: "Debug | fix", Author: "Kernighan", Tags: []string{"a", "b"}, Year: 1974},
+		{ID: "def", Text: "Code", Author: "Unknown"},
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+		format string
+		want   string
+	}{
+		{
+			name:   "text",
+			fields: []string{"id", "author"},
+			format: "text",
+			want:   "abc\tKernighan\ndef\tUnknown\n",
+		},
+		{
+			name:   "text with tags and year",
+			fields: []string{"tags", "year"},
+			format: "text",
+			want:   "a, b\t1974\n\t\n",
+		},
+		{
+			name:   "markdown escapes pipes",
+			fields: []string{"id", "text"},
+			format: "markdown",
+			want:   "| id | text |\n| --- | --- |\n| abc | Debug \\| fix |\n| def | Code |\n",
+		},
+		{
+			name:   "html table",
+			fields: []string{"id", "text"},
+			format: "html",
+			want:   "<table class=\"quotes\">\n<thead>\n<tr><th>id</th><th>text</th></tr>\n</thead>\n<tbody>\n<tr><td>abc</td><td>Debug | fix</td></tr>\n<tr><td>def</td><td>Code</td></tr>\n</tbody>\n</table>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatFields(quotes, tt.fields, tt.format)
+			if got != tt.want {
+				t.Errorf("FormatFields() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("json keeps field order", func(t *testing.T) {
+		got := FormatFields(quotes, []string{"author", "id", "year"}, "json")
+
+		var parsed []map[string]interface{}
+		if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+			t.Fatalf("FormatFields() produced invalid JSON: %v", err)
+		}
+
+		if len(parsed) != 2 || len(parsed[0]) != 3 {
+			t.Fatalf("FormatFields() = %s, want 2 objects with 3 keys", got)
+		}
+		if parsed[0]["year"] != float64(1974) {
+			t.Errorf("year = %v, want 1974", parsed[0]["year"])
+		}
+		if strings.Index(got, `"author"`) > strings.Index(got, `"id"`) {
+			t.Errorf("FormatFields() did not keep field order: %s", got)
+		}
+	})
+}
+
+func TestListCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantErr  string
+		validate func(t *testing.T, output string)
+	}{
+		{
+			name: "whole collection",
+			args: []string{"list", "--format", "json"},
+			validate: func(t *testing.T, output string) {
+				var quotes []Quote
+				if err := json.Unmarshal([]byte(output), &quotes); err != nil {
+					t.Fatalf("failed to parse JSON: %v", err)
+				}
+				if len(quotes) != len(defaultQuotes) {
+					t.Errorf("expected %d quotes, got %d", len(defaultQuotes), len(quotes))
+				}
+			},
+		},
+		{
+			name: "filtered and sorted",
+			args: []string{"list", "--author", "kernighan", "--sort", "length", "--fields", "text"},
+			validate: func(t *testing.T, output string) {
+				lines := strings.Split(strings.TrimSpace(output), "\n")
+				if len(lines) != 3 {
+					t.Fatalf("expected 3 lines, got %d: %q", len(lines), output)
+				}
+				if lines[0] != "Don't comment bad code – rewrite it" {
+					t.Errorf("expected shortest Kernighan quote first, got %q", lines[0])
+				}
+			},
+		},
+		{
+			name: "limit and offset",
+			args: []string{"list", "--sort", "id", "--offset", "2", "--limit", "3", "--fields", "id"},
+			validate: func(t *testing.T, output string) {
+				lines := strings.Split(strings.TrimSpace(output), "\n")
+				if len(lines) != 3 {
+					t.Errorf("expected 3 lines, got %d: %q", len(lines), output)
+				}
+			},
+		},
+		{
+			name: "markdown",
+			args: []string{"list", "--format", "markdown", "--limit", "1"},
+			validate: func(t *testing.T, output string) {
+				if !strings.HasPrefix(output, "> ") {
+					t.Errorf("expected markdown blockquote, got %q", output)
+				}
+			},
+		},
+		{
+			name:    "invalid field",
+			args:    []string{"list", "--fields", "text,color"},
+			wantErr: "invalid field",
+		},
+		{
+			name:    "invalid sort",
+			args:    []string{"list", "--sort", "year"},
+			wantErr: "invalid sort key",
+		},
+		{
+			name:    "invalid format",
+			args:    []string{"list", "--format", "xml"},
+			wantErr: "invalid format",
+		},
+		{
+			name:    "negative limit",
+			args:    []string{"list", "--limit", "-1"},
+			wantErr: "must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newRootCommand()
+			output, err := executeCommand(cmd, tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tt.validate(t, output)
+		})
+	}
+}
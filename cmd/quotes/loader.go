@@ -1,36 +1,364 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode/utf8"
 )
 
-// LoadQuotes returns quotes from ~/.quotes.json if it exists and is valid,
-// otherwise returns the default hardcoded quotes.
-// Never returns nil or an empty slice - always provides usable quotes.
-func LoadQuotes() []Quote {
-	// Try to get user's home directory
+// warnOutput receives warnings about quote files that were ignored
+var warnOutput io.Writer = os.Stderr
+
+// stdin is read when a quotes file is given as "-"
+var stdin io.Reader = os.Stdin
+
+// Problem describes a single issue found while validating a quotes file.
+// Line and Column are 1-based; Entry is the 0-based array index of the quote
+// the problem belongs to, or -1 for problems with the file as a whole.
+type Problem struct {
+	Line    int
+	Column  int
+	Entry   int
+	Message string
+}
+
+// String formats the problem as "line:column: message"
+func (p Problem) String() string {
+	if p.Entry >= 0 {
+		return fmt.Sprintf("%d:%d: entry %d: %s", p.Line, p.Column, p.Entry, p.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+}
+
+// ValidationError reports every problem found in a quotes file
+type ValidationError struct {
+	Path     string
+	Problems []Problem
+}
+
+// Error lists each problem on its own line, prefixed with the file path
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = e.Path + ":" + p.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stringFields lists the quote keys that hold strings; tags and year are the
+// only other keys a quote entry may have
+var stringFields = []string{"id", "text", "author", "source", "url", "language", "notes"}
+
+// reservedIDs can't be quote IDs because the HTTP API serves other endpoints
+// where GET /quotes/{id} would fetch them
+var reservedIDs = []string{"random", "today", "search"}
+
+// overridePath returns the path of the user's ~/.quotes.json override file
+func overridePath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return defaultQuotes
+		return "", err
 	}
+	return filepath.Join(home, ".quotes.json"), nil
+}
+
+// LoadQuotes returns the quotes from every source in the resolution chain
+// (QUOTES_PATH, $XDG_CONFIG_HOME/quotes and ~/.quotes.json) combined with the
+// default hardcoded quotes as each source's mode dictates, or just the
+// defaults when no sources exist or none are valid.
+// Every returned quote carries an ID, derived from its content when the file
+// doesn't supply one. A warning is written to stderr for each file that is
+// ignored.
+// Never returns nil or an empty slice - always provides usable quotes.
+func LoadQuotes() []Quote {
+	quotes, _ := loadCollection(nil, false)
+	return quotes
+}
 
-	// Check for override file
-	overridePath := filepath.Join(home, ".quotes.json")
-	data, err := os.ReadFile(overridePath)
+// QuoteFile is the parsed content of a quotes file: either a bare array of
+// quotes, which replaces the defaults, or an object of the form
+// {"mode": "replace"|"append", "quotes": [...]}.
+type QuoteFile struct {
+	Mode   SourceMode
+	Quotes []Quote
+}
+
+// LoadQuotesFile reads and validates a quotes file. Returns a
+// *ValidationError listing every problem if the file is not well formed or
+// holds no quotes.
+func LoadQuotesFile(path string) ([]Quote, error) {
+	file, err := ReadQuoteFile(path)
+	return file.Quotes, err
+}
+
+// ReadQuoteFile reads and validates a quotes file, or standard input when
+// path is "-"
+func ReadQuoteFile(path string) (QuoteFile, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
 	if err != nil {
-		// File doesn't exist or is unreadable - use defaults
-		return defaultQuotes
+		return QuoteFile{}, err
+	}
+
+	file, problems := ParseQuoteFile(data)
+	if len(problems) > 0 {
+		return QuoteFile{}, &ValidationError{Path: path, Problems: problems}
+	}
+
+	return file, nil
+}
+
+// ParseQuotes decodes a quotes file and returns its quotes. See ParseQuoteFile.
+func ParseQuotes(data []byte) ([]Quote, []Problem) {
+	file, problems := ParseQuoteFile(data)
+	return file.Quotes, problems
+}
+
+// ParseQuoteFile decodes a quotes file, reporting syntax errors, missing
+// required fields, wrongly typed fields and unknown keys with their line and
+// column. Keys are matched case-insensitively, as encoding/json does.
+func ParseQuoteFile(data []byte) (QuoteFile, []Problem) {
+	problem := func(offset, entry int, format string, args ...interface{}) Problem {
+		line, column := lineColumn(data, offset)
+		return Problem{Line: line, Column: column, Entry: entry, Message: fmt.Sprintf(format, args...)}
+	}
+
+	// Check the syntax of the whole document first so the offset is absolute
+	if err := json.Unmarshal(data, new(interface{})); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// The offset points just past the offending character
+			return QuoteFile{}, []Problem{problem(int(syntaxErr.Offset)-1, -1, "%s", syntaxErr.Error())}
+		}
+		return QuoteFile{}, []Problem{problem(0, -1, "%s", err.Error())}
+	}
+
+	start := skipSpace(data, 0, "")
+	switch data[start] {
+	case '[':
+		quotes, problems := parseEntries(data[start:], func(local, entry int, format string, args ...interface{}) Problem {
+			return problem(start+local, entry, format, args...)
+		})
+		return QuoteFile{Mode: ModeReplace, Quotes: quotes}, problems
+	case '{':
+		// Handled below
+	default:
+		return QuoteFile{}, []Problem{problem(start, -1, "expected a JSON array of quotes or a collection object, got %s", jsonKind(data[start:]))}
+	}
+
+	file := QuoteFile{Mode: ModeReplace}
+	var problems []Problem
+	hasQuotes := false
+
+	forEachMember(data[start:], func(key string, keyOffset int, value json.RawMessage, valueOffset int) {
+		switch strings.ToLower(key) {
+		case "mode":
+			var mode string
+			json.Unmarshal(value, &mode)
+			if SourceMode(mode) != ModeReplace && SourceMode(mode) != ModeAppend {
+				problems = append(problems, problem(start+valueOffset, -1, "%q must be %q or %q", key, ModeReplace, ModeAppend))
+				return
+			}
+			file.Mode = SourceMode(mode)
+		case "quotes":
+			hasQuotes = true
+			if value[0] != '[' {
+				problems = append(problems, problem(start+valueOffset, -1, "%q must be an array of quotes, got %s", key, jsonKind(value)))
+				return
+			}
+			quotes, entryProblems := parseEntries(value, func(local, entry int, format string, args ...interface{}) Problem {
+				return problem(start+valueOffset+local, entry, format, args...)
+			})
+			file.Quotes = quotes
+			problems = append(problems, entryProblems...)
+		default:
+			problems = append(problems, problem(start+keyOffset, -1, "unknown key %q", key))
+		}
+	})
+
+	if !hasQuotes {
+		problems = append(problems, problem(start, -1, "missing %q", "quotes"))
 	}
 
-	// Try to parse the override file
+	if len(problems) > 0 {
+		return QuoteFile{}, problems
+	}
+
+	return file, nil
+}
+
+// parseEntries decodes a JSON array of quote objects. problem builds a
+// Problem from an offset relative to the start of raw.
+func parseEntries(raw json.RawMessage, problem func(offset, entry int, format string, args ...interface{}) Problem) ([]Quote, []Problem) {
 	var quotes []Quote
-	if err := json.Unmarshal(data, &quotes); err != nil || len(quotes) == 0 {
-		// Invalid JSON or empty array - fall back to defaults
-		return defaultQuotes
+	var problems []Problem
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.Token() // opening bracket
+	for entry := 0; dec.More(); entry++ {
+		offset := skipSpace(raw, int(dec.InputOffset()), ",")
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, append(problems, problem(offset, entry, "%s", err.Error()))
+		}
+
+		entryProblems := validateEntry(value, func(local int, format string, args ...interface{}) Problem {
+			return problem(offset+local, entry, format, args...)
+		})
+		if len(entryProblems) > 0 {
+			problems = append(problems, entryProblems...)
+			continue
+		}
+
+		var q Quote
+		json.Unmarshal(value, &q)
+		quotes = append(quotes, q)
 	}
 
-	// Successfully loaded override quotes
-	return quotes
+	if len(quotes) == 0 && len(problems) == 0 {
+		problems = append(problems, problem(0, -1, "file contains no quotes"))
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return withIDs(quotes), nil
+}
+
+// validateEntry checks a single array element. problem builds a Problem from
+// an offset relative to the start of raw.
+func validateEntry(raw json.RawMessage, problem func(offset int, format string, args ...interface{}) Problem) []Problem {
+	if raw[0] != '{' {
+		return []Problem{problem(0, "expected a quote object, got %s", jsonKind(raw))}
+	}
+
+	var problems []Problem
+	seen := make(map[string]bool)
+
+	forEachMember(raw, func(key string, keyOffset int, value json.RawMessage, valueOffset int) {
+		name := strings.ToLower(key)
+		seen[name] = true
+
+		switch {
+		case contains(stringFields, name):
+			var text string
+			if value[0] != '"' || json.Unmarshal(value, &text) != nil {
+				problems = append(problems, problem(valueOffset, "%q must be a string, got %s", key, jsonKind(value)))
+			} else if (name == "text" || name == "author") && text == "" {
+				problems = append(problems, problem(valueOffset, "%q must not be empty", key))
+			} else if name == "id" && contains(reservedIDs, text) {
+				problems = append(problems, problem(valueOffset, "%q must not be %q, which is reserved", key, text))
+			}
+		case name == "tags":
+			var tags []string
+			if value[0] != '[' {
+				problems = append(problems, problem(valueOffset, "%q must be an array of strings, got %s", key, jsonKind(value)))
+			} else if json.Unmarshal(value, &tags) != nil {
+				problems = append(problems, problem(valueOffset, "%q must contain only strings", key))
+			}
+		case name == "year":
+			var year int
+			if json.Unmarshal(value, &year) != nil {
+				problems = append(problems, problem(valueOffset, "%q must be an integer, got %s", key, jsonKind(value)))
+			}
+		default:
+			problems = append(problems, problem(keyOffset, "unknown key %q", key))
+		}
+	})
+
+	for _, required := range []string{"text", "author"} {
+		if !seen[required] {
+			problems = append(problems, problem(0, "missing %q", required))
+		}
+	}
+
+	return problems
+}
+
+// forEachMember calls fn for every member of the JSON object in raw, which
+// must already be known to be valid, with the offsets of its key and value
+// relative to the start of raw
+func forEachMember(raw json.RawMessage, fn func(key string, keyOffset int, value json.RawMessage, valueOffset int)) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.Token() // opening brace
+	for dec.More() {
+		keyOffset := skipSpace(raw, int(dec.InputOffset()), ",")
+		token, _ := dec.Token()
+		valueOffset := skipSpace(raw, int(dec.InputOffset()), ":")
+
+		var value json.RawMessage
+		dec.Decode(&value)
+
+		fn(token.(string), keyOffset, value, valueOffset)
+	}
+}
+
+// jsonKind describes the type of the JSON value at the start of raw
+func jsonKind(raw []byte) string {
+	if len(raw) == 0 {
+		return "nothing"
+	}
+
+	switch raw[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+// skipSpace returns the offset of the first byte at or after offset that is
+// neither whitespace nor one of the extra separator characters
+func skipSpace(data []byte, offset int, extra string) int {
+	for offset < len(data) && strings.IndexByte(" \t\r\n"+extra, data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// lineColumn converts a byte offset into a 1-based line and column, counting
+// columns in characters
+func lineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -175,3 +179,360 @@ func TestLoadQuotes_NeverReturnsNilOrEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadQuotes_ExtendedModel(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("Cannot get user home directory")
+	}
+
+	overridePath := filepath.Join(home, ".quotes.json")
+
+	data, err := os.ReadFile(filepath.Join("testdata", "extended-quotes.json"))
+	if err != nil {
+		t.Fatalf("Failed to read testdata: %v", err)
+	}
+
+	err = os.WriteFile(overridePath, data, 0644)
+	if err != nil {
+		t.Fatalf("Failed to create test override file: %v", err)
+	}
+	defer os.Remove(overridePath)
+
+	quotes := LoadQuotes()
+
+	if len(quotes) != 2 {
+		t.Fatalf("Expected 2 override quotes, got %d", len(quotes))
+	}
+
+	first := quotes[0]
+	if first.ID != "kernighan-debugging" {
+		t.Errorf("Expected explicit ID to be kept, got %q", first.ID)
+	}
+	if first.Source != "The Elements of Programming Style" || first.Year != 1974 || first.Language != "en" {
+		t.Errorf("Metadata not loaded: %+v", first)
+	}
+	if len(first.Tags) != 2 || first.Tags[0] != "debugging" {
+		t.Errorf("Expected tags [debugging humor], got %v", first.Tags)
+	}
+
+	if quotes[1].ID != QuoteID(quotes[1].Text, quotes[1].Author) {
+		t.Errorf("Expected content-derived ID for two-field quote, got %q", quotes[1].ID)
+	}
+}
+
+func TestLoadQuotes_AssignsIDsToDefaults(t *testing.T) {
+	isolateSources(t)
+
+	for _, q := range LoadQuotes() {
+		if q.ID == "" {
+			t.Fatalf("Default quote %q has no ID", q.Text)
+		}
+	}
+}
+
+func TestParseQuotes_Problems(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string // expected Problem strings
+	}{
+		{
+			name: "syntax error",
+			data: "[\n  {\"text\": \"a\" \"author\": \"b\"}\n]",
+			want: []string{"2:16: invalid character '\"' after object key:value pair"},
+		},
+		{
+			name: "not an array",
+			data: `  "just text"`,
+			want: []string{"1:3: expected a JSON array of quotes or a collection object, got string"},
+		},
+		{
+			name: "collection object problems",
+			data: `{"mode": "merge", "quote": []}`,
+			want: []string{
+				`1:10: "mode" must be "replace" or "append"`,
+				`1:19: unknown key "quote"`,
+				`1:1: missing "quotes"`,
+			},
+		},
+		{
+			name: "collection object entry positions",
+			data: "{\"quotes\": [\n  {\"text\": \"a\"}\n]}",
+			want: []string{`2:3: entry 0: missing "author"`},
+		},
+		{
+			name: "empty array",
+			data: `[]`,
+			want: []string{"1:1: file contains no quotes"},
+		},
+		{
+			name: "entry not an object",
+			data: `["just text"]`,
+			want: []string{"1:2: entry 0: expected a quote object, got string"},
+		},
+		{
+			name: "missing and empty fields",
+			data: `[{"text": ""}]`,
+			want: []string{
+				`1:11: entry 0: "text" must not be empty`,
+				`1:2: entry 0: missing "author"`,
+			},
+		},
+		{
+			name: "wrong types",
+			data: `[{"text": 1, "author": "b", "tags": "x", "year": 1.5}, {"text": "a", "author": "b", "tags": [1]}]`,
+			want: []string{
+				`1:11: entry 0: "text" must be a string, got number`,
+				`1:37: entry 0: "tags" must be an array of strings, got string`,
+				`1:50: entry 0: "year" must be an integer, got number`,
+				`1:93: entry 1: "tags" must contain only strings`,
+			},
+		},
+		{
+			name: "reserved id",
+			data: `[{"id": "random", "text": "a", "author": "b"}, {"id": "se\u0061rch", "text": "a", "author": "b"}]`,
+			want: []string{
+				`1:9: entry 0: "id" must not be "random", which is reserved`,
+				`1:55: entry 1: "id" must not be "search", which is reserved`,
+			},
+		},
+		{
+			name: "unknown key",
+			data: `[{"text": "a", "author": "b", "colour": "blue"}]`,
+			want: []string{`1:31: entry 0: unknown key "colour"`},
+		},
+		{
+			name: "capitalised keys accepted",
+			data: `[{"Text": "a", "Author": "b"}]`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotes, problems := ParseQuotes([]byte(tt.data))
+
+			if len(problems) != len(tt.want) {
+				t.Fatalf("ParseQuotes() problems = %v, want %v", problems, tt.want)
+			}
+
+			for i, p := range problems {
+				if p.String() != tt.want[i] {
+					t.Errorf("ParseQuotes() problem[%d] = %q, want %q", i, p.String(), tt.want[i])
+				}
+			}
+
+			if len(tt.want) == 0 && len(quotes) == 0 {
+				t.Error("ParseQuotes() returned no quotes for a valid file")
+			}
+		})
+	}
+}
+
+func TestLoadQuotesFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		wantQuotes   int
+		wantProblems []string
+	}{
+		{
+			name:       "valid",
+			file:       "valid-quotes.json",
+			wantQuotes: 3,
+		},
+		{
+			name:         "invalid JSON",
+			file:         "invalid-quotes.json",
+			wantProblems: []string{"4:3: invalid character '\"' after object key:value pair"},
+		},
+		{
+			name: "per-entry problems",
+			file: "problem-quotes.json",
+			wantProblems: []string{
+				`6:3: entry 1: missing "author"`,
+				`12:13: entry 2: "year" must be an integer, got string`,
+				`13:5: entry 2: unknown key "colour"`,
+				`15:3: entry 3: expected a quote object, got string`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.file)
+			quotes, err := LoadQuotesFile(path)
+
+			if len(tt.wantProblems) == 0 {
+				if err != nil {
+					t.Fatalf("LoadQuotesFile() unexpected error: %v", err)
+				}
+				if len(quotes) != tt.wantQuotes {
+					t.Errorf("LoadQuotesFile() returned %d quotes, want %d", len(quotes), tt.wantQuotes)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("LoadQuotesFile() error = %v, want *ValidationError", err)
+			}
+
+			if len(validationErr.Problems) != len(tt.wantProblems) {
+				t.Fatalf("LoadQuotesFile() problems = %v, want %v", validationErr.Problems, tt.wantProblems)
+			}
+
+			for i, p := range validationErr.Problems {
+				if p.String() != tt.wantProblems[i] {
+					t.Errorf("problem[%d] = %q, want %q", i, p.String(), tt.wantProblems[i])
+				}
+			}
+
+			if !strings.HasPrefix(err.Error(), path+":") {
+				t.Errorf("error %q should be prefixed with the file path", err)
+			}
+		})
+	}
+}
+
+func TestLoadQuotes_WarnsOnFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  *string
+		wantWarn bool
+	}{
+		{name: "missing file is silent", content: nil, wantWarn: false},
+		{name: "valid file is silent", content: ptr(`[{"text": "a", "author": "b"}]`), wantWarn: false},
+		{name: "invalid JSON warns", content: ptr(`{this is not valid json`), wantWarn: true},
+		{name: "empty array warns", content: ptr(`[]`), wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			if tt.content != nil {
+				if err := os.WriteFile(filepath.Join(home, ".quotes.json"), []byte(*tt.content), 0644); err != nil {
+					t.Fatalf("Failed to create test override file: %v", err)
+				}
+			}
+
+			var warnings bytes.Buffer
+			warnOutput = &warnings
+			defer func() { warnOutput = os.Stderr }()
+
+			LoadQuotes()
+
+			if got := warnings.Len() > 0; got != tt.wantWarn {
+				t.Errorf("warning written = %v, want %v (output %q)", got, tt.wantWarn, warnings.String())
+			}
+			if tt.wantWarn && !strings.Contains(warnings.String(), "using built-in quotes") {
+				t.Errorf("warning %q should mention the fallback", warnings.String())
+			}
+		})
+	}
+}
+
+func TestStrictFlag(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := os.WriteFile(filepath.Join(home, ".quotes.json"), []byte(`[]`), 0644); err != nil {
+		t.Fatalf("Failed to create test override file: %v", err)
+	}
+
+	warnOutput = io.Discard
+	defer func() { warnOutput = os.Stderr }()
+
+	if _, err := executeCommand(newRootCommand()); err != nil {
+		t.Errorf("non-strict run should fall back to defaults, got error: %v", err)
+	}
+
+	_, err := executeCommand(newRootCommand(), "--strict")
+	if err == nil || !strings.Contains(err.Error(), "file contains no quotes") {
+		t.Errorf("strict run error = %v, want file contains no quotes", err)
+	}
+
+	_, err = executeCommand(newRootCommand(), "list", "--strict")
+	if err == nil {
+		t.Error("strict list should fail on an invalid override file")
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantErr    string
+		wantOutput string
+	}{
+		{
+			name:       "valid file",
+			args:       []string{"validate", filepath.Join("testdata", "valid-quotes.json")},
+			wantOutput: "ok (3 quotes, replace)",
+		},
+		{
+			name:       "invalid file",
+			args:       []string{"validate", filepath.Join("testdata", "problem-quotes.json")},
+			wantErr:    "4 problem(s) found",
+			wantOutput: `problem-quotes.json:13:5: entry 2: unknown key "colour"`,
+		},
+		{
+			name:    "missing file",
+			args:    []string{"validate", filepath.Join("testdata", "does-not-exist.json")},
+			wantErr: "no such file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := executeCommand(newRootCommand(), tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(output, tt.wantOutput) {
+				t.Errorf("output %q does not contain %q", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestParseQuoteFile_Modes(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantMode SourceMode
+	}{
+		{name: "bare array replaces", data: `[{"text": "a", "author": "b"}]`, wantMode: ModeReplace},
+		{name: "object defaults to replace", data: `{"quotes": [{"text": "a", "author": "b"}]}`, wantMode: ModeReplace},
+		{name: "explicit replace", data: `{"mode": "replace", "quotes": [{"text": "a", "author": "b"}]}`, wantMode: ModeReplace},
+		{name: "append", data: `{"mode": "append", "quotes": [{"text": "a", "author": "b"}]}`, wantMode: ModeAppend},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, problems := ParseQuoteFile([]byte(tt.data))
+			if len(problems) > 0 {
+				t.Fatalf("ParseQuoteFile() problems = %v", problems)
+			}
+			if file.Mode != tt.wantMode {
+				t.Errorf("ParseQuoteFile() mode = %q, want %q", file.Mode, tt.wantMode)
+			}
+			if len(file.Quotes) != 1 || file.Quotes[0].Text != "a" {
+				t.Errorf("ParseQuoteFile() quotes = %+v", file.Quotes)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockTimeout bounds how long lockPath waits for another process
+const lockTimeout = 10 * time.Second
+
+// lockPath takes an exclusive lock on path by creating path+".lock", which
+// fails while another process holds it, retrying until lockTimeout passes.
+// The returned function releases the lock.
+func lockPath(path string) (func(), error) {
+	lock := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lock, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s", lock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
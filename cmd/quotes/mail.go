@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// mailPasswordEnv names the environment variable holding the SMTP password,
+// which is kept out of flags so it doesn't show up in process listings
+const mailPasswordEnv = "QUOTES_SMTP_PASSWORD"
+
+// mailTimeout bounds a whole SMTP conversation
+const mailTimeout = 30 * time.Second
+
+// defaultMailSubject is the subject template used unless --subject is given
+const defaultMailSubject = `Quotes for {{.Date.Format "Monday 2 January 2006"}}`
+
+// errNoStartTLS is returned when the server can't encrypt the connection and
+// sending in the clear wasn't allowed
+var errNoStartTLS = errors.New("SMTP server does not support STARTTLS (use --insecure to send without it)")
+
+var (
+	mailHost     string
+	mailPort     int
+	mailUsername string
+	mailFrom     string
+	mailTo       []string
+	mailSubject  string
+	mailInsecure bool
+	mailDryRun   bool
+)
+
+// mailServer is an SMTP server to submit messages to
+type mailServer struct {
+	host     string
+	port     int
+	username string
+	password string
+
+	// insecure allows sending without STARTTLS
+	insecure bool
+
+	// tlsConfig overrides the TLS settings, which otherwise verify the
+	// server's certificate against the system roots
+	tlsConfig *tls.Config
+}
+
+// mailHTML is the HTML part of a digest. Styles are inline because many mail
+// clients drop style elements.
+var mailHTML = template.Must(template.New("mail").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Subject}}</title>
+</head>
+<body style="margin:0;padding:24px;background:#f4f4f1;color:#222;">
+<div style="max-width:600px;margin:0 auto;">
+{{- range .Quotes}}
+<blockquote style="margin:0 0 24px;padding:16px 20px;background:#ffffff;border-left:4px solid #5865f2;border-radius:4px;">
+<p style="margin:0;font-family:Georgia,'Times New Roman',serif;font-size:18px;line-height:1.5;">{{range $i, $line := .Lines}}{{if $i}}<br>{{end}}{{$line}}{{end}}</p>
+<p style="margin:12px 0 0;font-family:Helvetica,Arial,sans-serif;font-size:14px;color:#666;">&mdash; {{.Author}}{{if .Source}}, <cite>{{.Source}}</cite>{{end}}{{if .Year}} ({{.Year}}){{end}}{{if .URL}} &middot; <a href="{{.URL}}" style="color:#5865f2;">Read more</a>{{end}}</p>
+</blockquote>
+{{- end}}
+</div>
+</body>
+</html>
+`))
+
+// newMailCommand creates the mail subcommand, which emails a quote digest
+func newMailCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mail",
+		Short: "Email a quote digest over SMTP",
+		Long: `Email quotes to a list of recipients through an SMTP server.
+
+The message has a plain-text part, as "quotes" prints, and an HTML part laying
+the quotes out as styled blockquotes. The connection is upgraded with STARTTLS
+before anything is sent, and --username logs in with PLAIN authentication
+using the password in QUOTES_SMTP_PASSWORD.
+
+--subject is a Go template executed with .Date (a time.Time), .Quotes, .Quote
+(the first quote) and .Count, for example:
+
+  --subject 'Monday motivation from {{.Quote.Author}}'
+
+Quotes are picked like the root command, so --count, --seed, --deck and the
+filter flags work the same way.`,
+		Args: cobra.NoArgs,
+		RunE: runMail,
+	}
+
+	cmd.Flags().StringVar(&mailHost, "host", "", "SMTP server host name")
+	cmd.Flags().IntVar(&mailPort, "port", 587, "SMTP server port")
+	cmd.Flags().StringVar(&mailUsername, "username", "", "User to log in as; the password is read from $"+mailPasswordEnv)
+	cmd.Flags().StringVar(&mailFrom, "from", "", "Sender address, e.g. 'Quotes <quotes@example.com>'")
+	cmd.Flags().StringArrayVar(&mailTo, "to", nil, "Recipient address (repeatable)")
+	cmd.Flags().StringVar(&mailSubject, "subject", defaultMailSubject, "Subject line template")
+	cmd.Flags().BoolVar(&mailInsecure, "insecure", false, "Send without STARTTLS if the server doesn't offer it")
+	cmd.Flags().BoolVar(&mailDryRun, "dry-run", false, "Print the message instead of sending it")
+	cmd.Flags().IntVarP(&count, "count", "n", 1, "Number of quotes (1-100)")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Random seed for reproducibility")
+	cmd.Flags().BoolVar(&allowRepeats, "allow-repeats", false, "Allow repeated quotes when count exceeds the collection size")
+	cmd.Flags().BoolVar(&useDeck, "deck", false, "Deal from a persistent shuffled deck so quotes don't repeat across runs")
+
+	return cmd
+}
+
+// runMail picks quotes and mails them as a digest
+func runMail(cmd *cobra.Command, args []string) error {
+	if mailFrom == "" || len(mailTo) == 0 {
+		return fmt.Errorf("--from and --to are required")
+	}
+	if mailHost == "" && !mailDryRun {
+		return fmt.Errorf("--host is required")
+	}
+
+	// Check the template before dealing, so a typo doesn't use up the deck
+	if _, err := parseTemplate("subject", mailSubject); err != nil {
+		return fmt.Errorf("invalid --subject template: %w", err)
+	}
+
+	selected, err := pickQuotes()
+	if err != nil {
+		return err
+	}
+
+	msg, err := mailDigest(mailFrom, mailTo, mailSubject, selected, FormatText(selected), now())
+	if err != nil {
+		return err
+	}
+
+	if mailDryRun {
+		fmt.Print(string(msg))
+		return nil
+	}
+
+	server := mailServer{
+		host:     mailHost,
+		port:     mailPort,
+		username: mailUsername,
+		password: os.Getenv(mailPasswordEnv),
+		insecure: mailInsecure,
+	}
+	return sendMail(server, mailFrom, mailTo, msg)
+}
+
+// renderSubject executes the subject template with data
+func renderSubject(subject string, data templateData) (string, error) {
+	tmpl, err := parseTemplate("subject", subject)
+	if err != nil {
+		return "", fmt.Errorf("invalid subject template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("subject template: %w", err)
+	}
+
+	// A subject is a single header line
+	return strings.Join(strings.Fields(b.String()), " "), nil
+}
+
+// mailDigest builds a multipart/alternative message holding quotes as HTML
+// and as the plain text in text, with the subject rendered from the subject
+// template
+func mailDigest(from string, to []string, subject string, quotes []Quote, text string, date time.Time) ([]byte, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", from, err)
+	}
+	recipients := make([]string, len(to))
+	for i, address := range to {
+		recipient, err := mail.ParseAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to address %q: %w", address, err)
+		}
+		recipients[i] = recipient.String()
+	}
+
+	if subject, err = renderSubject(subject, newTemplateData(quotes, date)); err != nil {
+		return nil, err
+	}
+
+	type htmlQuote struct {
+		Quote
+		Lines []string
+	}
+	page := struct {
+		Subject string
+		Quotes  []htmlQuote
+	}{Subject: subject}
+	for _, q := range quotes {
+		page.Quotes = append(page.Quotes, htmlQuote{Quote: q, Lines: strings.Split(q.Text, "\n")})
+	}
+
+	var html bytes.Buffer
+	if err := mailHTML.Execute(&html, page); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	parts := multipart.NewWriter(&msg)
+
+	fmt.Fprintf(&msg, "From: %s\r\n", sender)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Message-ID: %s\r\n", messageID(sender.Address))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html.String()},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n")))
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// messageID returns a new Message-ID in the sender's domain
+func messageID(address string) string {
+	domain := "localhost"
+	if _, d, ok := strings.Cut(address, "@"); ok {
+		domain = d
+	}
+
+	random := make([]byte, 8)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
+
+// sendMail submits msg to server for each address in to. The connection is
+// upgraded with STARTTLS before logging in or sending, and only continues
+// unencrypted when the server is insecure and doesn't offer it.
+func sendMail(server mailServer, from string, to []string, msg []byte) error {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("invalid from address %q: %w", from, err)
+	}
+
+	addr := net.JoinHostPort(server.host, strconv.Itoa(server.port))
+	conn, err := net.DialTimeout("tcp", addr, mailTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(mailTimeout))
+
+	c, err := smtp.NewClient(conn, server.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		config := server.tlsConfig
+		if config == nil {
+			config = &tls.Config{ServerName: server.host}
+		}
+		if err := c.StartTLS(config); err != nil {
+			return fmt.Errorf("STARTTLS: %w", err)
+		}
+	} else if !server.insecure {
+		return errNoStartTLS
+	}
+
+	if server.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", server.username, server.password, server.host)); err != nil {
+			return fmt.Errorf("SMTP login failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(sender.Address); err != nil {
+		return err
+	}
+	for _, address := range to {
+		recipient, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("invalid to address %q: %w", address, err)
+		}
+		if err := c.Rcpt(recipient.Address); err != nil {
+			return fmt.Errorf("recipient %s: %w", recipient.Address, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP is a local SMTP stand-in that accepts a single message and
+// records what the client sent
+type fakeSMTP struct {
+	port      int
+	tlsConfig *tls.Config
+	done      chan struct{}
+
+	usedTLS bool
+	auth    string
+	from    string
+	to      []string
+	data    string
+}
+
+// newFakeSMTP starts a server, offering STARTTLS when withTLS is set, and
+// returns it with a client TLS config that trusts its certificate
+func newFakeSMTP(t *testing.T, withTLS bool) (*fakeSMTP, *tls.Config) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &fakeSMTP{port: listener.Addr().(*net.TCPAddr).Port, done: make(chan struct{})}
+	var clientConfig *tls.Config
+	if withTLS {
+		s.tlsConfig, clientConfig = testTLSConfigs(t)
+	}
+
+	go func() {
+		defer close(s.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		s.handle(conn)
+	}()
+
+	return s, clientConfig
+}
+
+// handle speaks just enough SMTP for net/smtp to deliver a message
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-fake")
+			if s.tlsConfig != nil && !s.usedTLS {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 go ahead")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, tp, s.usedTLS = tlsConn, textproto.NewConn(tlsConn), true
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			s.auth = string(decoded)
+			tp.PrintfLine("235 accepted")
+		case "MAIL":
+			s.from = arg
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			s.to = append(s.to, arg)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 send it")
+			data, _ := tp.ReadDotBytes()
+			s.data = string(data)
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// testTLSConfigs returns a server config with a self-signed certificate for
+// 127.0.0.1 and a client config that trusts it
+func testTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cannot generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cannot create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client := &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"}
+	return server, client
+}
+
+func TestRenderSubject(t *testing.T) {
+	data := templateData{
+		Date:   time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC),
+		Quotes: []Quote{{Text: "a"}, {Text: "b"}},
+		Quote:  Quote{Text: "a", Author: "Grace Hopper"},
+		Count:  2,
+	}
+
+	tests := []struct {
+		subject string
+		want    string
+		wantErr bool
+	}{
+		{defaultMailSubject, "Quotes for Monday 19 October 2026", false},
+		{"Monday motivation from {{.Quote.Author}}", "Monday motivation from Grace Hopper", false},
+		{"{{.Count}} quotes\nfor you", "2 quotes for you", false},
+		{"{{.Count", "", true},
+		{"{{.Missing}}", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			got, err := renderSubject(tt.subject, data)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("renderSubject() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderSubject() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderSubject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMailDigest(t *testing.T) {
+	quotes := []Quote{
+		{ID: "1", Text: "Simplicity is prerequisite for reliability.", Author: "Edsger Dijkstra", Source: "EWD498", Year: 1975},
+		{ID: "2", Text: "Less is <more>.", Author: "Ludwig Mies van der Rohe", URL: "https://example.com/mies"},
+	}
+	date := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	data, err := mailDigest("Quotes <quotes@example.com>", []string{"team@example.com"}, "Motivación {{.Count}}", quotes, FormatText(quotes), date)
+	if err != nil {
+		t.Fatalf("mailDigest() unexpected error: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Motivación 2" {
+		t.Errorf("Subject = %q, want %q", subject, "Motivación 2")
+	}
+	if got := msg.Header.Get("From"); got != `"Quotes" <quotes@example.com>` {
+		t.Errorf("From = %q", got)
+	}
+	if !strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Message-ID = %q, want one in the sender's domain", msg.Header.Get("Message-ID"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	bodies := make(map[string]string)
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid part: %v", err)
+		}
+		body, _ := io.ReadAll(part)
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = strings.ReplaceAll(string(body), "\r\n", "\n")
+	}
+
+	if got, want := bodies["text/plain"], FormatText(quotes); got != want {
+		t.Errorf("text part = %q, want %q", got, want)
+	}
+	for _, want := range []string{"<blockquote", "Less is &lt;more&gt;.", "<cite>EWD498</cite> (1975)", `href="https://example.com/mies"`} {
+		if !strings.Contains(bodies["text/html"], want) {
+			t.Errorf("HTML part missing %q:\n%s", want, bodies["text/html"])
+		}
+	}
+}
+
+func TestSendMail(t *testing.T) {
+	server, clientConfig := newFakeSMTP(t, true)
+
+	msg := []byte("Subject: hello\r\n\r\nbody\r\n")
+	err := sendMail(mailServer{
+		host:      "127.0.0.1",
+		port:      server.port,
+		username:  "quotes",
+		password:  "secret",
+		tlsConfig: clientConfig,
+	}, "Quotes <quotes@example.com>", []string{"a@example.com", "B <b@example.com>"}, msg)
+	if err != nil {
+		t.Fatalf("sendMail() unexpected error: %v", err)
+	}
+	<-server.done
+
+	if !server.usedTLS {
+		t.Error("message was sent without STARTTLS")
+	}
+	if server.auth != "\x00quotes\x00secret" {
+		t.Errorf("PLAIN credentials = %q", server.auth)
+	}
+	if server.from != "FROM:<quotes@example.com>" {
+		t.Errorf("MAIL %s, want FROM:<quotes@example.com>", server.from)
+	}
+	if len(server.to) != 2 || server.to[1] != "TO:<b@example.com>" {
+		t.Errorf("RCPT = %v", server.to)
+	}
+	if !strings.Contains(server.data, "Subject: hello") {
+		t.Errorf("DATA = %q", server.data)
+	}
+}
+
+func TestSendMail_WithoutStartTLS(t *testing.T) {
+	server, _ := newFakeSMTP(t, false)
+	err := sendMail(mailServer{host: "127.0.0.1", port: server.port}, "quotes@example.com", []string{"a@example.com"}, []byte("\r\n"))
+	if !errors.Is(err, errNoStartTLS) {
+		t.Errorf("sendMail() error = %v, want %v", err, errNoStartTLS)
+	}
+
+	server, _ = newFakeSMTP(t, false)
+	err = sendMail(mailServer{host: "127.0.0.1", port: server.port, insecure: true}, "quotes@example.com", []string{"a@example.com"}, []byte("\r\n"))
+	if err != nil {
+		t.Errorf("sendMail() with insecure unexpected error: %v", err)
+	}
+}
+
+func TestMailCommand_DryRun(t *testing.T) {
+	isolateSources(t)
+
+	output, err := executeCommand(newRootCommand(), "mail", "--dry-run", "--from", "quotes@example.com", "--to", "team@example.com", "-n", "3", "--subject", "{{.Count}} quotes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"Subject: 3 quotes\r\n", "multipart/alternative", "text/html"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+}
+
+func TestMailCommand_Errors(t *testing.T) {
+	isolateSources(t)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"missing recipients", []string{"mail", "--host", "mail", "--from", "a@example.com"}, "--from and --to"},
+		{"missing host", []string{"mail", "--from", "a@example.com", "--to", "b@example.com"}, "--host"},
+		{"invalid subject", []string{"mail", "--dry-run", "--from", "a@example.com", "--to", "b@example.com", "--subject", "{{"}, "invalid --subject"},
+		{"invalid address", []string{"mail", "--dry-run", "--from", "not an address", "--to", "b@example.com"}, "invalid from address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executeCommand(newRootCommand(), tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
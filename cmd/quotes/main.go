@@ -10,9 +10,11 @@ import (
 )
 
 var (
-	format string
-	count  int
-	seed   int64
+	format       string
+	count        int
+	seed         int64
+	allowRepeats bool
+	strict       bool
 )
 
 // newRootCommand creates and returns the root command
@@ -24,9 +26,32 @@ func newRootCommand() *cobra.Command {
 		RunE:  runQuotes,
 	}
 
-	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: text|json|markdown")
+	addOutputFlags(cmd)
 	cmd.Flags().IntVarP(&count, "count", "n", 1, "Number of quotes (1-100)")
 	cmd.Flags().Int64Var(&seed, "seed", 0, "Random seed for reproducibility")
+	cmd.Flags().BoolVar(&allowRepeats, "allow-repeats", false, "Allow repeated quotes when count exceeds the collection size")
+	cmd.Flags().BoolVar(&useDeck, "deck", false, "Deal from a persistent shuffled deck so quotes don't repeat across runs")
+	cmd.PersistentFlags().StringArrayVar(&quoteFiles, "file", nil, "Quotes file or directory to load instead of the default chain (repeatable, - for stdin)")
+	cmd.PersistentFlags().BoolVar(&strict, "strict", false, "Fail instead of falling back to built-in quotes when a quotes file is invalid")
+	addFilterFlags(cmd)
+
+	cmd.AddCommand(newCardCommand())
+	cmd.AddCommand(newDiscordCommand())
+	cmd.AddCommand(newExportCommand())
+	cmd.AddCommand(newListCommand())
+	cmd.AddCommand(newMailCommand())
+	cmd.AddCommand(newMCPCommand())
+	cmd.AddCommand(newPostCommand())
+	cmd.AddCommand(newScheduleCommand())
+	cmd.AddCommand(newSearchCommand())
+	cmd.AddCommand(newServeCommand())
+	cmd.AddCommand(newSlackCommand())
+	cmd.AddCommand(newQotdCommand())
+	cmd.AddCommand(newFingerCommand())
+	cmd.AddCommand(newGopherCommand())
+	cmd.AddCommand(newStreamCommand())
+	cmd.AddCommand(newTodayCommand())
+	cmd.AddCommand(newValidateCommand())
 
 	return cmd
 }
@@ -34,54 +59,85 @@ func newRootCommand() *cobra.Command {
 // isValidFormat checks if the provided format is valid
 func isValidFormat(format string) bool {
 	switch format {
-	case "text", "json", "markdown":
+	case "text", "json", "markdown", "html", "say":
 		return true
 	default:
 		return false
 	}
 }
 
+// loadFilteredQuotes loads the active collection, honouring --file and
+// --strict, and narrows it with the filter flags
+func loadFilteredQuotes() ([]Quote, error) {
+	filter, err := buildFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	quotes, err := loadCollection(quoteFiles, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterQuotes(quotes, filter)
+}
+
 // runQuotes is the main command execution function
 func runQuotes(cmd *cobra.Command, args []string) error {
 	// Validate format
-	if !isValidFormat(format) {
-		return fmt.Errorf("invalid format: %s (must be one of: text, json, markdown)", format)
+	out, err := newOutput()
+	if err != nil {
+		return err
+	}
+
+	selected, err := pickQuotes()
+	if err != nil {
+		return err
+	}
+
+	// Format and print
+	result, err := out.render(selected)
+	if err != nil {
+		return err
 	}
+	fmt.Print(result)
+	return nil
+}
 
+// pickQuotes selects quotes as the --count, --seed, --allow-repeats and
+// --deck flags ask, from the collection narrowed by the filter flags
+func pickQuotes() ([]Quote, error) {
 	// Validate count
 	if count < 1 || count > 100 {
-		return fmt.Errorf("count must be 1-100, got %d", count)
+		return nil, fmt.Errorf("count must be 1-100, got %d", count)
 	}
 
-	// Load quotes
-	quotes := LoadQuotes()
+	// Load quotes and narrow them to the requested subset
+	quotes, err := loadFilteredQuotes()
+	if err != nil {
+		return nil, err
+	}
 
 	// Use current time as seed if not specified
 	if seed == 0 {
 		seed = time.Now().UnixNano()
 	}
 
-	// Select random quotes
-	selected := make([]Quote, 0, count)
-	for i := 0; i < count; i++ {
-		// Use different seed for each selection to avoid duplicates
-		q, _ := SelectRandom(quotes, seed+int64(i))
-		selected = append(selected, q)
-	}
-
-	// Format and print
-	var output string
-	switch format {
-	case "json":
-		output = FormatJSON(selected)
-	case "markdown":
-		output = FormatMarkdown(selected)
-	default:
-		output = FormatText(selected)
+	if useDeck {
+		// Deal the next quotes from the persisted deck for this collection
+		if count > len(quotes) && !allowRepeats {
+			return nil, fmt.Errorf("%w: requested %d but only %d available (use --allow-repeats)", ErrNotEnoughQuotes, count, len(quotes))
+		}
+
+		path, err := deckPath()
+		if err != nil {
+			return nil, err
+		}
+		return DealFromDeck(path, quotes, count, seed)
 	}
 
-	fmt.Print(output)
-	return nil
+	// Select distinct quotes from a seeded permutation of the collection
+	return SelectQuotes(quotes, count, seed, allowRepeats)
 }
 
 func main() {
@@ -37,6 +37,62 @@ func executeCommand(cmd *cobra.Command, args ...string) (string, error) {
 	format = "text"
 	count = 1
 	seed = 0
+	allowRepeats = false
+	useDeck = false
+	strict = false
+	quoteFiles = nil
+	todayPeriod = "day"
+	todayTimezone = "UTC"
+	todayDate = ""
+	filterAuthor = ""
+	filterExactAuthor = false
+	filterMinLength = 0
+	filterMaxLength = 0
+	filterMatch = ""
+	filterTag = ""
+	listSort = ""
+	listReverse = false
+	listLimit = 0
+	listOffset = 0
+	listFields = nil
+	searchLimit = 10
+	searchShowScore = false
+	searchFuzzy = true
+	discordPrintCommand = false
+	htmlStandalone = false
+	htmlTheme = "light"
+	templateSource = ""
+	textWidth = 0
+	textColor = "auto"
+	textColorTheme = "default"
+	textFrame = "none"
+	sayCharacter = "cow"
+	cardOutput = ""
+	cardType = ""
+	cardSize = "square"
+	cardBackground = "midnight"
+	cardForeground = ""
+	exportFormat = "pdf"
+	exportOutput = ""
+	exportTitle = "Quotes"
+	exportCompact = false
+	exportPageSize = "a5"
+	exportSort = ""
+	postWebhookURL = ""
+	postStyle = "generic"
+	postRetries = 3
+	postDryRun = false
+	scheduleConfigPath = ""
+	scheduleStatePath = ""
+	scheduleList = false
+	mailHost = ""
+	mailPort = 587
+	mailUsername = ""
+	mailFrom = ""
+	mailTo = nil
+	mailSubject = defaultMailSubject
+	mailInsecure = false
+	mailDryRun = false
 
 	return buf.String(), err
 }
@@ -252,7 +308,7 @@ func TestQuotesCommand_FlagCombinations(t *testing.T) {
 }
 
 func TestQuotesCommand_InvalidFormat(t *testing.T) {
-	invalidFormats := []string{"xml", "yaml", "csv", "html", ""}
+	invalidFormats := []string{"xml", "yaml", "csv", "htm", ""}
 
 	for _, format := range invalidFormats {
 		t.Run("format_"+format, func(t *testing.T) {
@@ -286,3 +342,58 @@ func TestQuotesCommand_CountWithJSON(t *testing.T) {
 		t.Errorf("expected 3 quotes, got %d", len(quotes))
 	}
 }
+
+func TestQuotesCommand_CountReturnsDistinctQuotes(t *testing.T) {
+	cmd := newRootCommand()
+	output, err := executeCommand(cmd, "--format", "json", "--count", "50", "--seed", "20251110")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var quotes []Quote
+	if err := json.Unmarshal([]byte(output), &quotes); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+
+	if len(quotes) != 50 {
+		t.Fatalf("expected 50 quotes, got %d", len(quotes))
+	}
+
+	seen := make(map[string]bool)
+	for _, q := range quotes {
+		if seen[q.Text] {
+			t.Errorf("duplicate quote in output: %q", q.Text)
+		}
+		seen[q.Text] = true
+	}
+}
+
+func TestQuotesCommand_AllowRepeats(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{
+			name:    "count exceeds collection",
+			args:    []string{"--count", "100"},
+			wantErr: true,
+		},
+		{
+			name:    "count exceeds collection with repeats",
+			args:    []string{"--count", "100", "--allow-repeats"},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newRootCommand()
+			_, err := executeCommand(cmd, tt.args...)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("wantErr = %v, got error: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
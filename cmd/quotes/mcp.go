@@ -0,0 +1,592 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// mcpProtocolVersions lists the MCP revisions the server speaks, newest
+// first
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// maxMessageSize bounds a single JSON-RPC message read from stdin
+const maxMessageSize = 1 << 20
+
+// addedQuotesFile names the file add_quote writes to in the config directory
+const addedQuotesFile = "added.json"
+
+// stdout receives the MCP server's responses; tests replace it
+var stdout io.Writer = os.Stdout
+
+// newMCPCommand creates the mcp subcommand, a Model Context Protocol server
+// on stdin and stdout
+func newMCPCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Run a Model Context Protocol server on stdin and stdout",
+		Long: `Serve the collection to LLM agents over the Model Context Protocol, speaking
+newline-delimited JSON-RPC on stdin and stdout.
+
+Tools:
+  random_quote       distinct random quotes, optionally filtered
+  search_quotes      ranked full-text search
+  quote_of_the_day   the quote of the day, week or month
+  add_quote          add a quote to $XDG_CONFIG_HOME/quotes/added.json
+
+With --file, add_quote is refused unless added.json or its directory is one
+of the files, since the quotes it adds would never be loaded.
+
+Register it with an MCP client as the command "quotes mcp".`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := newQuoteStore(loadFilteredQuotes)
+			if err != nil {
+				return err
+			}
+			return serveMCP(store, stdin, stdout)
+		},
+	}
+
+	return cmd
+}
+
+// rpcRequest is a JSON-RPC request or notification. Notifications have no
+// ID.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse carries either a result or an error
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// serveMCP answers MCP messages from in on out, one per line, until in is
+// exhausted
+func serveMCP(store *quoteStore, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		if response := handleMCPMessage(store, line); response != nil {
+			if err := encoder.Encode(response); err != nil {
+				return err
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// handleMCPMessage answers one message, returning nil for notifications
+func handleMCPMessage(store *quoteStore, message []byte) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(message, &req); err != nil {
+		return &rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &rpcResponse{JSONRPC: "2.0", ID: idOrNull(req.ID), Error: &rpcError{Code: rpcInvalidRequest, Message: "expected a JSON-RPC 2.0 request"}}
+	}
+
+	result, err := dispatchMCP(store, req)
+	if req.ID == nil {
+		return nil
+	}
+
+	response := &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		response.Result, response.Error = nil, rpcErr
+	}
+	return response
+}
+
+// idOrNull returns id, or a JSON null when the request had none
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// dispatchMCP runs the method named by req
+func dispatchMCP(store *quoteStore, req rpcRequest) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+
+		// Agree to the client's version when we speak it, else offer ours
+		version := mcpProtocolVersions[0]
+		if contains(mcpProtocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": "quotes", "version": buildVersion()},
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": mcpTools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return callTool(store, params.Name, params.Arguments)
+	default:
+		if strings.HasPrefix(req.Method, "notifications/") {
+			return nil, nil
+		}
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+}
+
+// buildVersion reports the module version the binary was built from
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// mcpTool describes a tool in a tools/list result
+type mcpTool struct {
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	OutputSchema map[string]interface{} `json:"outputSchema"`
+}
+
+// quoteSchema is the JSON schema of a Quote
+var quoteSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"id":       map[string]interface{}{"type": "string"},
+		"text":     map[string]interface{}{"type": "string"},
+		"author":   map[string]interface{}{"type": "string"},
+		"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"source":   map[string]interface{}{"type": "string"},
+		"year":     map[string]interface{}{"type": "integer"},
+		"url":      map[string]interface{}{"type": "string"},
+		"language": map[string]interface{}{"type": "string"},
+		"notes":    map[string]interface{}{"type": "string"},
+	},
+	"required": []string{"id", "text", "author"},
+}
+
+// objectSchema builds the schema of an object with the given properties
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// property builds the schema of a single typed property
+func property(kind, description string) map[string]interface{} {
+	return map[string]interface{}{"type": kind, "description": description}
+}
+
+// mcpTools lists the tools the server offers
+var mcpTools = []mcpTool{
+	{
+		Name:        "random_quote",
+		Description: "Pick distinct random quotes from the collection, optionally filtered by author, tag, length or a regular expression.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"count":        map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 100, "default": 1, "description": "Number of quotes"},
+			"seed":         property("integer", "Random seed for reproducible picks"),
+			"author":       property("string", "Only quotes whose author contains this text (case-insensitive)"),
+			"exact_author": property("boolean", "Require author to match the whole author name"),
+			"min_length":   property("integer", "Only quotes with at least this many characters"),
+			"max_length":   property("integer", "Only quotes with at most this many characters"),
+			"match":        property("string", "Only quotes whose text matches this regular expression"),
+			"tag":          property("string", "Only quotes with this tag (case-insensitive)"),
+		}),
+		OutputSchema: objectSchema(map[string]interface{}{
+			"quotes": map[string]interface{}{"type": "array", "items": quoteSchema},
+		}, "quotes"),
+	},
+	{
+		Name:        "search_quotes",
+		Description: "Search quote text and authors, best match first. Supports \"exact phrases\" and -excluded terms.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"query": property("string", "Search terms"),
+			"limit": map[string]interface{}{"type": "integer", "minimum": 0, "default": 10, "description": "Maximum number of results, 0 for all"},
+			"fuzzy": map[string]interface{}{"type": "boolean", "default": true, "description": "Match words within one or two edits of unknown terms"},
+		}, "query"),
+		OutputSchema: objectSchema(map[string]interface{}{
+			"results": map[string]interface{}{"type": "array", "items": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"quote": quoteSchema, "score": map[string]interface{}{"type": "number"}},
+				"required":   []string{"quote", "score"},
+			}},
+		}, "results"),
+	},
+	{
+		Name:        "quote_of_the_day",
+		Description: "The deterministic quote for the current day, week or month, the same for everyone sharing the collection.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"period":   map[string]interface{}{"type": "string", "enum": []string{"day", "week", "month"}, "default": "day"},
+			"timezone": map[string]interface{}{"type": "string", "default": "UTC", "description": "IANA timezone that decides when a period starts"},
+			"date":     map[string]interface{}{"type": "string", "pattern": `^\d{4}-\d{2}-\d{2}$`, "description": "Date to look up instead of today (YYYY-MM-DD)"},
+		}),
+		OutputSchema: objectSchema(map[string]interface{}{
+			"quote":  quoteSchema,
+			"period": property("string", "The period the quote belongs to, e.g. 2026-10-17, 2026-W42 or 2026-10"),
+		}, "quote", "period"),
+	},
+	{
+		Name:        "add_quote",
+		Description: "Add a quote to the user's collection. It is available to every later call.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"text":     property("string", "The quote"),
+			"author":   property("string", "Who said it"),
+			"tags":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"source":   property("string", "Book, talk or article it comes from"),
+			"year":     property("integer", "Year it was said or published"),
+			"url":      property("string", "Where to read more"),
+			"language": property("string", "Language code, e.g. en"),
+			"notes":    property("string", "Free-form notes"),
+		}, "text", "author"),
+		OutputSchema: objectSchema(map[string]interface{}{
+			"quote": quoteSchema,
+			"path":  property("string", "File the quote was added to"),
+		}, "quote", "path"),
+	},
+}
+
+// callTool runs the named tool. Problems with the arguments or the
+// collection are reported in the result with isError set, so the model can
+// see them; only an unknown tool is a protocol error.
+func callTool(store *quoteStore, name string, arguments json.RawMessage) (interface{}, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+
+	var structured interface{}
+	var err error
+	switch name {
+	case "random_quote":
+		structured, err = toolRandomQuote(store.Collection(), arguments)
+	case "search_quotes":
+		structured, err = toolSearchQuotes(store.Collection(), arguments)
+	case "quote_of_the_day":
+		structured, err = toolQuoteOfTheDay(store.Collection(), arguments)
+	case "add_quote":
+		structured, err = toolAddQuote(store, arguments)
+	default:
+		return nil, &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf("unknown tool: %s", name)}
+	}
+
+	if err != nil {
+		return map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}, nil
+	}
+
+	text, _ := json.Marshal(structured)
+	return map[string]interface{}{
+		"content":           []map[string]string{{"type": "text", "text": string(text)}},
+		"structuredContent": structured,
+		"isError":           false,
+	}, nil
+}
+
+// decodeArguments decodes tool arguments into v, rejecting unknown keys
+func decodeArguments(arguments json.RawMessage, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(arguments))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// toolRandomQuote picks distinct random quotes
+func toolRandomQuote(c *collection, arguments json.RawMessage) (interface{}, error) {
+	args := struct {
+		Count       int    `json:"count"`
+		Seed        *int64 `json:"seed"`
+		Author      string `json:"author"`
+		ExactAuthor bool   `json:"exact_author"`
+		MinLength   int    `json:"min_length"`
+		MaxLength   int    `json:"max_length"`
+		Match       string `json:"match"`
+		Tag         string `json:"tag"`
+	}{Count: 1}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	if args.Count < 1 || args.Count > 100 {
+		return nil, fmt.Errorf("count must be 1-100, got %d", args.Count)
+	}
+
+	filter := Filter{
+		Author:      args.Author,
+		ExactAuthor: args.ExactAuthor,
+		MinLength:   args.MinLength,
+		MaxLength:   args.MaxLength,
+		Tag:         args.Tag,
+	}
+	if filter.MinLength < 0 || filter.MaxLength < 0 {
+		return nil, fmt.Errorf("length bounds must not be negative")
+	}
+	if filter.MaxLength > 0 && filter.MinLength > filter.MaxLength {
+		return nil, fmt.Errorf("min_length %d exceeds max_length %d", filter.MinLength, filter.MaxLength)
+	}
+	if args.Match != "" {
+		re, err := regexp.Compile(args.Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match pattern: %w", err)
+		}
+		filter.Match = re
+	}
+
+	quotes, err := FilterQuotes(c.quotes, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := time.Now().UnixNano()
+	if args.Seed != nil {
+		seed = *args.Seed
+	}
+
+	selected, err := SelectQuotes(quotes, args.Count, seed, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"quotes": selected}, nil
+}
+
+// toolSearchQuotes runs a ranked search
+func toolSearchQuotes(c *collection, arguments json.RawMessage) (interface{}, error) {
+	args := struct {
+		Query string `json:"query"`
+		Limit *int   `json:"limit"`
+		Fuzzy *bool  `json:"fuzzy"`
+	}{}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	limit, fuzzy := 10, true
+	if args.Limit != nil {
+		limit = *args.Limit
+	}
+	if args.Fuzzy != nil {
+		fuzzy = *args.Fuzzy
+	}
+
+	type result struct {
+		Quote Quote   `json:"quote"`
+		Score float64 `json:"score"`
+	}
+
+	found, err := c.index.Search(args.Query, fuzzy)
+	if errors.Is(err, ErrNoResults) {
+		return map[string]interface{}{"results": []result{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+
+	results := make([]result, len(found))
+	for i, r := range found {
+		results[i] = result{Quote: r.Quote, Score: r.Score}
+	}
+	return map[string]interface{}{"results": results}, nil
+}
+
+// toolQuoteOfTheDay picks the quote of the period
+func toolQuoteOfTheDay(c *collection, arguments json.RawMessage) (interface{}, error) {
+	args := struct {
+		Period   string `json:"period"`
+		Timezone string `json:"timezone"`
+		Date     string `json:"date"`
+	}{Period: "day", Timezone: "UTC"}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(args.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", args.Timezone)
+	}
+
+	t := now().In(loc)
+	if args.Date != "" {
+		if t, err = time.ParseInLocation("2006-01-02", args.Date, loc); err != nil {
+			return nil, fmt.Errorf("invalid date: %s (must be YYYY-MM-DD)", args.Date)
+		}
+	}
+
+	q, err := QuoteOfThePeriod(c.quotes, t, args.Period)
+	if err != nil {
+		return nil, err
+	}
+	key, _ := PeriodKey(t, args.Period)
+
+	return map[string]interface{}{"quote": q, "period": key}, nil
+}
+
+// toolAddQuote appends a quote to the user's added.json and reloads the
+// collection so later calls see it
+func toolAddQuote(store *quoteStore, arguments json.RawMessage) (interface{}, error) {
+	var q Quote
+	if err := decodeArguments(arguments, &q); err != nil {
+		return nil, err
+	}
+	q.ID = ""
+
+	dir, err := quotesConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, addedQuotesFile)
+	if !isQuoteSource(path) {
+		return nil, fmt.Errorf("%s is not one of the server's quote sources, so an added quote would never show up; restart the server with --file %s as well", path, path)
+	}
+
+	added, err := AddQuote(path, q)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Reload(); err != nil {
+		return nil, fmt.Errorf("added the quote, but reloading failed: %w", err)
+	}
+
+	return map[string]interface{}{"quote": added, "path": path}, nil
+}
+
+// isQuoteSource reports whether the file at path is loaded with the
+// collection, whether or not it exists yet. Without --file the chain always
+// includes the config directory; with it, path or its directory must be one
+// of the given files.
+func isQuoteSource(path string) bool {
+	if len(quoteFiles) == 0 {
+		return true
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, file := range quoteFiles {
+		if file, err := filepath.Abs(file); err == nil && (file == path || file == filepath.Dir(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddQuote appends q to the quotes file at path, creating it in append mode
+// if it doesn't exist. The quote must pass the same checks as any entry in a
+// quotes file, and a quote whose ID is already in the file is rejected.
+func AddQuote(path string, q Quote) (Quote, error) {
+	entry, _ := json.Marshal([]Quote{q})
+	if _, problems := ParseQuoteFile(entry); len(problems) > 0 {
+		return Quote{}, fmt.Errorf("invalid quote: %s", problems[0].Message)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Quote{}, err
+	}
+
+	unlock, err := lockPath(path)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer unlock()
+
+	file := QuoteFile{Mode: ModeAppend}
+	if _, err := os.Stat(path); err == nil {
+		if file, err = ReadQuoteFile(path); err != nil {
+			return Quote{}, err
+		}
+	}
+
+	q = withIDs([]Quote{q})[0]
+	for _, existing := range file.Quotes {
+		if existing.ID == q.ID {
+			return Quote{}, fmt.Errorf("quote %s is already in %s", q.ID, path)
+		}
+	}
+	file.Quotes = append(file.Quotes, q)
+
+	data, err := json.MarshalIndent(map[string]interface{}{"mode": file.Mode, "quotes": file.Quotes}, "", "  ")
+	if err != nil {
+		return Quote{}, err
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return Quote{}, err
+	}
+
+	return q, nil
+}
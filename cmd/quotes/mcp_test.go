@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// mcpSession sends each message to a fresh server over the built-in quotes
+// and returns the decoded responses
+func mcpSession(t *testing.T, messages ...string) []map[string]interface{} {
+	t.Helper()
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return loadCollection(nil, true)
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := serveMCP(store, strings.NewReader(strings.Join(messages, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("serveMCP() unexpected error: %v", err)
+	}
+
+	var responses []map[string]interface{}
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var response map[string]interface{}
+		if err := dec.Decode(&response); err != nil {
+			t.Fatalf("invalid response JSON: %v", err)
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// toolCall builds a tools/call request
+func toolCall(id int, name, arguments string) string {
+	return `{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"tools/call","params":{"name":"` + name + `","arguments":` + arguments + `}}`
+}
+
+func TestMCPProtocol(t *testing.T) {
+	isolateSources(t)
+
+	responses := mcpSession(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+		`not json`,
+		`{"id":5,"method":"ping"}`,
+		toolCall(6, "no_such_tool", `{}`),
+	)
+
+	// The notification gets no response
+	if len(responses) != 7 {
+		t.Fatalf("got %d responses, want 7: %v", len(responses), responses)
+	}
+
+	init := responses[0]["result"].(map[string]interface{})
+	if init["protocolVersion"] != "2025-03-26" {
+		t.Errorf("protocolVersion = %v, want the client's 2025-03-26", init["protocolVersion"])
+	}
+
+	tools := responses[1]["result"].(map[string]interface{})["tools"].([]interface{})
+	var names []string
+	for _, tool := range tools {
+		tool := tool.(map[string]interface{})
+		names = append(names, tool["name"].(string))
+		if _, ok := tool["inputSchema"].(map[string]interface{}); !ok {
+			t.Errorf("tool %v has no inputSchema", tool["name"])
+		}
+	}
+	if got := strings.Join(names, ","); got != "random_quote,search_quotes,quote_of_the_day,add_quote" {
+		t.Errorf("tools = %s", got)
+	}
+
+	if _, ok := responses[2]["result"]; !ok {
+		t.Errorf("ping response = %v, want a result", responses[2])
+	}
+
+	wantCodes := []float64{rpcMethodNotFound, rpcParseError, rpcInvalidRequest, rpcInvalidParams}
+	for i, want := range wantCodes {
+		response := responses[3+i]
+		rpcErr, ok := response["error"].(map[string]interface{})
+		if !ok {
+			t.Errorf("response %d = %v, want an error", 3+i, response)
+			continue
+		}
+		if rpcErr["code"] != want {
+			t.Errorf("response %d error code = %v, want %v", 3+i, rpcErr["code"], want)
+		}
+	}
+}
+
+func TestMCPTools(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        string
+		arguments   string
+		wantError   bool
+		wantContain string
+	}{
+		{name: "random", tool: "random_quote", arguments: `{"count":3,"seed":1}`, wantContain: `"quotes":[`},
+		{name: "random filtered", tool: "random_quote", arguments: `{"author":"dijkstra"}`, wantContain: `"author":"Edsger Dijkstra"`},
+		{name: "random no matches", tool: "random_quote", arguments: `{"author":"nobody"}`, wantError: true, wantContain: "no quotes match"},
+		{name: "random invalid count", tool: "random_quote", arguments: `{"count":0}`, wantError: true, wantContain: "count must be 1-100"},
+		{name: "random unknown argument", tool: "random_quote", arguments: `{"colour":"red"}`, wantError: true, wantContain: "unknown field"},
+		{name: "random bad pattern", tool: "random_quote", arguments: `{"match":"("}`, wantError: true, wantContain: "invalid match pattern"},
+		{name: "search", tool: "search_quotes", arguments: `{"query":"debugging","limit":2}`, wantContain: `"score":`},
+		{name: "search no results", tool: "search_quotes", arguments: `{"query":"xylophone","fuzzy":false}`, wantContain: `"results":[]`},
+		{name: "search empty", tool: "search_quotes", arguments: `{"query":""}`, wantError: true},
+		{name: "today", tool: "quote_of_the_day", arguments: `{"date":"2026-10-17","period":"week"}`, wantContain: `"period":"2026-W42"`},
+		{name: "today invalid timezone", tool: "quote_of_the_day", arguments: `{"timezone":"Mars/Olympus"}`, wantError: true, wantContain: "invalid timezone"},
+		{name: "add missing author", tool: "add_quote", arguments: `{"text":"Hello"}`, wantError: true, wantContain: "invalid quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateSources(t)
+
+			responses := mcpSession(t, toolCall(1, tt.tool, tt.arguments))
+			result := responses[0]["result"].(map[string]interface{})
+
+			if result["isError"] != tt.wantError {
+				t.Errorf("isError = %v, want %v: %v", result["isError"], tt.wantError, result)
+			}
+
+			text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+			if !strings.Contains(text, tt.wantContain) {
+				t.Errorf("content %q does not contain %q", text, tt.wantContain)
+			}
+
+			if _, ok := result["structuredContent"]; ok == tt.wantError {
+				t.Errorf("structuredContent present = %v, want %v", ok, !tt.wantError)
+			}
+		})
+	}
+}
+
+func TestMCPAddQuote(t *testing.T) {
+	home := isolateSources(t)
+
+	responses := mcpSession(t,
+		toolCall(1, "add_quote", `{"text":"Zebras compile faster","author":"Test Author","tags":["test"]}`),
+		toolCall(2, "search_quotes", `{"query":"zebras"}`),
+		toolCall(3, "add_quote", `{"text":"Zebras compile faster","author":"Test Author"}`),
+	)
+
+	added := responses[0]["result"].(map[string]interface{})
+	if added["isError"] != false {
+		t.Fatalf("add_quote failed: %v", added)
+	}
+
+	found := responses[1]["result"].(map[string]interface{})["structuredContent"].(map[string]interface{})["results"].([]interface{})
+	if len(found) != 1 {
+		t.Errorf("search after add found %d results, want 1", len(found))
+	}
+
+	if duplicate := responses[2]["result"].(map[string]interface{}); duplicate["isError"] != true {
+		t.Errorf("adding a duplicate = %v, want an error", duplicate)
+	}
+
+	path := filepath.Join(home, "config", "quotes", addedQuotesFile)
+	file, err := ReadQuoteFile(path)
+	if err != nil {
+		t.Fatalf("ReadQuoteFile(%s) unexpected error: %v", path, err)
+	}
+	if file.Mode != ModeAppend || len(file.Quotes) != 1 {
+		t.Errorf("added.json has mode %s and %d quotes, want append and 1", file.Mode, len(file.Quotes))
+	}
+}
+
+func TestMCPAddQuote_NotASource(t *testing.T) {
+	home := isolateSources(t)
+	other := writeQuotes(t, filepath.Join(home, "other.json"), `{"mode": "replace", "quotes": [{"text": "Only", "author": "Me"}]}`)
+	quoteFiles = []string{other}
+	t.Cleanup(func() { quoteFiles = nil })
+
+	responses := mcpSession(t, toolCall(1, "add_quote", `{"text":"Zebras compile faster","author":"Test Author"}`))
+
+	result := responses[0]["result"].(map[string]interface{})
+	if result["isError"] != true {
+		t.Fatalf("add_quote = %v, want an error", result)
+	}
+	if _, err := os.Stat(filepath.Join(home, "config", "quotes", addedQuotesFile)); !os.IsNotExist(err) {
+		t.Errorf("add_quote wrote added.json anyway: %v", err)
+	}
+}
+
+func TestIsQuoteSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "quotes", addedQuotesFile)
+
+	tests := []struct {
+		name  string
+		files []string
+		want  bool
+	}{
+		{name: "source chain", want: true},
+		{name: "the file itself", files: []string{path}, want: true},
+		{name: "its directory", files: []string{"other.json", filepath.Join(dir, "quotes") + "/"}, want: true},
+		{name: "other files", files: []string{filepath.Join(dir, "other.json")}, want: false},
+		{name: "parent directory", files: []string{dir}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quoteFiles = tt.files
+			t.Cleanup(func() { quoteFiles = nil })
+
+			if got := isQuoteSource(path); got != tt.want {
+				t.Errorf("isQuoteSource() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddQuote_KeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeQuotes(t, filepath.Join(dir, "mine.json"), `[{"text": "First", "author": "Someone"}]`)
+
+	if _, err := AddQuote(path, Quote{Text: "Second", Author: "Someone"}); err != nil {
+		t.Fatalf("AddQuote() unexpected error: %v", err)
+	}
+
+	file, err := ReadQuoteFile(path)
+	if err != nil {
+		t.Fatalf("ReadQuoteFile() unexpected error: %v", err)
+	}
+	if file.Mode != ModeReplace || len(file.Quotes) != 2 {
+		t.Errorf("file has mode %s and %d quotes, want replace and 2", file.Mode, len(file.Quotes))
+	}
+
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) > 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// requestTimeout bounds how long a TCP client may take to send its request
+// and receive the reply
+const requestTimeout = 10 * time.Second
+
+// maxRequestLength bounds the request line of the line-based protocols
+const maxRequestLength = 1024
+
+// runUntilSignalled runs each serve function until one of them fails or
+// SIGTERM or SIGINT arrives, reloading store on SIGHUP. Callers close their
+// listeners afterwards, which ends the serve functions still running.
+func runUntilSignalled(store *quoteStore, serves ...func() error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	errs := make(chan error, len(serves))
+	for _, serve := range serves {
+		go func() { errs <- serve() }()
+	}
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case <-reload:
+			reloadStore(store)
+		case <-ctx.Done():
+			log.Printf("shutting down")
+			return nil
+		}
+	}
+}
+
+// serveLineRequests reads a one-line request from each connection on
+// listener, writes handle's reply and closes the connection, until listener
+// is closed. Clients over limiter's limit are disconnected without a reply.
+func serveLineRequests(listener net.Listener, limiter *rateLimiter, handle func(request string) string) error {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+			if !limiter.Allow(hostOf(conn.RemoteAddr())) {
+				return
+			}
+
+			conn.SetDeadline(time.Now().Add(requestTimeout))
+			request, err := readRequestLine(conn)
+			if err != nil {
+				return
+			}
+			fmt.Fprint(conn, handle(request))
+		}()
+	}
+}
+
+// readRequestLine reads a request terminated by CRLF or LF, without the line
+// ending
+func readRequestLine(conn net.Conn) (string, error) {
+	reader := bufio.NewReaderSize(conn, maxRequestLength)
+	line, err := reader.ReadSlice('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// listenError explains the usual reason a listener can't be opened
+func listenError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("%w (ports below 1024 usually need root; try --port 1717)", err)
+	}
+	return err
+}
+
+// hostOf returns the IP address of addr without its port, so every
+// connection from one machine shares a rate limit
+func hostOf(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// rateLimiter is a token bucket per client: each client may make limit
+// requests at once, regaining one every period/limit
+type rateLimiter struct {
+	limit   int
+	period  time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	pruned  time.Time
+}
+
+// bucket holds one client's remaining requests as of updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newRateLimiter creates a limiter allowing limit requests per period for
+// each client. A limit of 0 allows everything.
+func newRateLimiter(limit int, period time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		period:  period,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether client may make a request now, spending one of its
+// tokens if so
+func (l *rateLimiter) Allow(client string) bool {
+	if l.limit == 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), updated: now}
+		l.buckets[client] = b
+	}
+
+	refill := now.Sub(b.updated).Seconds() / l.period.Seconds() * float64(l.limit)
+	b.tokens = min(b.tokens+refill, float64(l.limit))
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune forgets clients idle for a whole period, whose buckets would be
+// full again anyway. It runs at most once a period.
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < l.period {
+		return
+	}
+	l.pruned = now
+
+	for client, b := range l.buckets {
+		if now.Sub(b.updated) >= l.period {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	clock := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	l := newRateLimiter(2, time.Minute)
+	l.now = func() time.Time { return clock }
+
+	steps := []struct {
+		advance time.Duration
+		client  string
+		want    bool
+	}{
+		{client: "a", want: true},
+		{client: "a", want: true},
+		{client: "a", want: false},
+		{client: "b", want: true},
+		{advance: 30 * time.Second, client: "a", want: true},
+		{client: "a", want: false},
+		{advance: 2 * time.Minute, client: "a", want: true},
+		{client: "a", want: true},
+		{client: "a", want: false},
+	}
+
+	for i, step := range steps {
+		clock = clock.Add(step.advance)
+		if got := l.Allow(step.client); got != step.want {
+			t.Errorf("step %d: Allow(%q) = %v, want %v", i, step.client, got, step.want)
+		}
+	}
+
+	if len(l.buckets) != 1 {
+		t.Errorf("idle clients were not pruned: %d buckets", len(l.buckets))
+	}
+}
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	l := newRateLimiter(0, time.Minute)
+	for i := 0; i < 100; i++ {
+		if !l.Allow("a") {
+			t.Fatalf("request %d refused with no limit", i)
+		}
+	}
+}
+
+func TestServeLineRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- serveLineRequests(listener, newRateLimiter(2, time.Minute), func(request string) string {
+			return "you sent " + request + "\r\n"
+		})
+	}()
+
+	ask := func(request string) string {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		fmt.Fprint(conn, request)
+		reply, _ := io.ReadAll(conn)
+		return string(reply)
+	}
+
+	if got := ask("hello\r\n"); got != "you sent hello\r\n" {
+		t.Errorf("CRLF request got %q", got)
+	}
+	if got := ask("bare\n"); got != "you sent bare\r\n" {
+		t.Errorf("LF request got %q", got)
+	}
+	if got := ask("again\r\n"); got != "" {
+		t.Errorf("rate-limited request got %q, want nothing", got)
+	}
+
+	listener.Close()
+	if err := <-done; err != nil {
+		t.Errorf("serveLineRequests() error = %v, want nil after close", err)
+	}
+}
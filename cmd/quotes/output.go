@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+// templateSource is the --template flag: a template file or inline text
+var templateSource string
+
+// output renders quotes as the --format, --template, HTML and text flags ask
+type output struct {
+	format string
+	text   textStyle
+
+	// character is the drawn art speaking with --format say
+	character string
+
+	// tmpl is the user template, which replaces format when set
+	tmpl *template.Template
+}
+
+// addOutputFlags registers --format, --template and the HTML, text and say flags
+// on a command that prints quotes
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format: text|json|markdown|html|say|tmpl:<name>")
+	cmd.Flags().StringVar(&templateSource, "template", "", "Render quotes with a Go template file, or inline template text (overrides --format)")
+	addHTMLFlags(cmd)
+	addTextFlags(cmd)
+	addSayFlags(cmd)
+}
+
+// newOutput validates the output flags and loads any template they name
+func newOutput() (*output, error) {
+	o := &output{format: format}
+
+	switch {
+	case templateSource != "":
+		tmpl, err := loadTemplate(templateSource)
+		if err != nil {
+			return nil, err
+		}
+		o.tmpl = tmpl
+	case isTemplateFormat(format):
+		tmpl, err := loadNamedTemplate(strings.TrimPrefix(format, templateFormatPrefix))
+		if err != nil {
+			return nil, err
+		}
+		o.tmpl = tmpl
+	case !isValidFormat(format):
+		return nil, fmt.Errorf("invalid format: %s (must be one of: text, json, markdown, html, say, tmpl:<name>)", format)
+	}
+
+	if o.tmpl != nil && htmlStandalone {
+		return nil, fmt.Errorf("--standalone cannot be used with a template")
+	}
+	if o.tmpl == nil {
+		if err := checkHTMLFlags(format); err != nil {
+			return nil, err
+		}
+	}
+
+	textFormat := format
+	if o.tmpl != nil {
+		textFormat = "template"
+	}
+	text, err := newTextStyle(os.Stdout, textFormat)
+	if err != nil {
+		return nil, err
+	}
+	o.text = text
+
+	if textFormat == "say" {
+		if o.character, err = loadCharacter(sayCharacter); err != nil {
+			return nil, err
+		}
+	} else if sayCharacter != "cow" {
+		return nil, fmt.Errorf("--character needs --format say")
+	}
+	return o, nil
+}
+
+// isTemplate reports whether quotes are rendered with a user template
+func (o *output) isTemplate() bool {
+	return o.tmpl != nil
+}
+
+// render formats quotes for printing
+func (o *output) render(quotes []Quote) (string, error) {
+	if o.tmpl == nil {
+		if o.format == "text" && !o.text.isPlain() {
+			return renderText(quotes, o.text), nil
+		}
+		if o.format == "say" {
+			width := textWidth
+			if width == 0 {
+				width = defaultSayWidth
+			}
+			return FormatSay(quotes, o.character, width), nil
+		}
+		return asDocument(o.format, formatQuotes(o.format, quotes)), nil
+	}
+
+	result, err := executeTemplate(o.tmpl, quotes, now())
+	if err != nil {
+		return "", err
+	}
+	if result != "" && !strings.HasSuffix(result, "\n") {
+		result += "\n"
+	}
+	return result, nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfFont is one of the standard PDF fonts, which viewers draw from their
+// own copies, with the character widths needed to lay out text in it. Text
+// is written in WinAnsiEncoding.
+type pdfFont struct {
+	resource string
+	baseFont string
+	// ascii holds the widths of ' ' to '~', in thousandths of the font size
+	ascii *[95]int
+	// special holds the widths of other encoded characters. Accented
+	// letters not listed are as wide as their plain letter, and everything
+	// else is 556.
+	special map[byte]int
+}
+
+// helveticaWidths are the widths of ' ' to '~' in Helvetica and
+// Helvetica-Oblique
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// helveticaBoldWidths are the widths of ' ' to '~' in Helvetica-Bold
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// helveticaSpecial and helveticaBoldSpecial are the widths of the punctuation
+// and symbols above '~' that the fonts don't set as wide as 556
+var helveticaSpecial = map[byte]int{
+	0x82: 222, 0x84: 333, 0x85: 1000, 0x91: 222, 0x92: 222, 0x93: 333, 0x94: 333,
+	0x95: 350, 0x97: 1000, 0x99: 1000, 0xA0: 278, 0xA9: 737, 0xB0: 400, 0xB7: 278,
+	0xC6: 1000, 0xD7: 584, 0xDF: 611, 0xE6: 889, 0xF7: 584,
+}
+
+var helveticaBoldSpecial = map[byte]int{
+	0x82: 278, 0x84: 500, 0x85: 1000, 0x91: 278, 0x92: 278, 0x93: 500, 0x94: 500,
+	0x95: 350, 0x97: 1000, 0x99: 1000, 0xA0: 278, 0xA9: 737, 0xB0: 400, 0xB7: 278,
+	0xC6: 1000, 0xD7: 584, 0xDF: 611, 0xE6: 889, 0xF7: 584,
+}
+
+// The fonts booklets are set in, in the order they're added to a PDF
+var (
+	helvetica        = &pdfFont{resource: "F1", baseFont: "Helvetica", ascii: &helveticaWidths, special: helveticaSpecial}
+	helveticaOblique = &pdfFont{resource: "F2", baseFont: "Helvetica-Oblique", ascii: &helveticaWidths, special: helveticaSpecial}
+	helveticaBold    = &pdfFont{resource: "F3", baseFont: "Helvetica-Bold", ascii: &helveticaBoldWidths, special: helveticaBoldSpecial}
+
+	pdfFonts = []*pdfFont{helvetica, helveticaOblique, helveticaBold}
+)
+
+// winAnsiSpecial maps the characters WinAnsiEncoding places between 0x80 and
+// 0x9F to their codes
+var winAnsiSpecial = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// winAnsi encodes s in WinAnsiEncoding. Tabs become spaces, and characters
+// the encoding lacks become '?'.
+func winAnsi(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			encoded = append(encoded, ' ')
+		case r >= ' ' && r <= '~', r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		case winAnsiSpecial[r] != 0:
+			encoded = append(encoded, winAnsiSpecial[r])
+		case runeWidth(r) > 0:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// charWidth returns the width of an encoded character, in thousandths of the
+// font size
+func (f *pdfFont) charWidth(c byte) int {
+	if c >= ' ' && c <= '~' {
+		return f.ascii[c-' ']
+	}
+	if width, ok := f.special[c]; ok {
+		return width
+	}
+	if folded, ok := foldedLetters[rune(c)]; ok {
+		return f.ascii[folded-' ']
+	}
+	return 556
+}
+
+// measure returns the width of s set at size points
+func (f *pdfFont) measure(s string, size float64) float64 {
+	total := 0
+	for _, c := range winAnsi(s) {
+		total += f.charWidth(c)
+	}
+	return float64(total) * size / 1000
+}
+
+// wrap breaks text into lines no wider than width points at size, keeping
+// existing line breaks. Words wider than width get a line of their own.
+func (f *pdfFont) wrap(text string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case f.measure(line+" "+word, size) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfDocument is a PDF being built page by page
+type pdfDocument struct {
+	title         string
+	width, height float64
+	pages         []*pdfPage
+}
+
+// pdfPage holds the drawing operators of one page
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+// newPDFDocument starts a PDF with pages of the given size, in points
+func newPDFDocument(title string, width, height float64) *pdfDocument {
+	return &pdfDocument{title: title, width: width, height: height}
+}
+
+// addPage appends a blank page to the document and returns it
+func (d *pdfDocument) addPage() *pdfPage {
+	page := &pdfPage{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// text draws s in font at size points with its baseline starting at x, y,
+// in a shade of gray from 0 (black) to 1 (white)
+func (p *pdfPage) text(font *pdfFont, size, x, y, gray float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s g %s %s Td %s Tj ET\n",
+		font.resource, pdfNumber(size), pdfNumber(gray), pdfNumber(x), pdfNumber(y), pdfString(winAnsi(s)))
+}
+
+// bytes writes the document as a PDF file
+func (d *pdfDocument) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-3 are the catalog, page tree and document info, followed
+	// by the fonts, then each page and its content stream
+	firstPage := 4 + len(pdfFonts)
+	var kids, fonts []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	for i, font := range pdfFonts {
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", font.resource, 4+i))
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>",
+		strings.Join(kids, " "), len(d.pages), pdfNumber(d.width), pdfNumber(d.height)))
+	object(fmt.Sprintf("<< /Title %s /Producer (quotes) >>", pdfTextString(d.title)))
+	for _, font := range pdfFonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.baseFont))
+	}
+
+	for i, page := range d.pages {
+		var stream bytes.Buffer
+		w := zlib.NewWriter(&stream)
+		if _, err := w.Write(page.content.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			strings.Join(fonts, " "), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes(), nil
+}
+
+// pdfNumber formats v with at most two decimal places
+func pdfNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// pdfString formats encoded text as a PDF literal string, escaping the
+// delimiters and writing bytes outside printable ASCII in octal
+func pdfString(encoded []byte) string {
+	var result strings.Builder
+	result.WriteByte('(')
+	for _, c := range encoded {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			result.WriteByte('\\')
+			result.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&result, "\\%03o", c)
+		default:
+			result.WriteByte(c)
+		}
+	}
+	result.WriteByte(')')
+	return result.String()
+}
+
+// pdfTextString formats s as a PDF text string, which document properties
+// such as the title are, in UTF-16 so any character survives
+func pdfTextString(s string) string {
+	var result strings.Builder
+	result.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&result, "%04X", unit)
+	}
+	result.WriteString(">")
+	return result.String()
+}
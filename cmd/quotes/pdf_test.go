@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pdfStreams returns the decompressed content stream of each page in a PDF
+// written by pdfDocument
+func pdfStreams(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	var streams []string
+	for _, match := range regexp.MustCompile(`(?s)/Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindAllSubmatchIndex(data, -1) {
+		length, err := strconv.Atoi(string(data[match[2]:match[3]]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data[match[1] : match[1]+length]))
+		if err != nil {
+			t.Fatalf("page %d: %v", len(streams)+1, err)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("page %d: %v", len(streams)+1, err)
+		}
+		streams = append(streams, string(content))
+	}
+	return streams
+}
+
+func TestWinAnsi(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "Plain (ASCII)", want: "Plain (ASCII)"},
+		{text: "café\tnaïve", want: "caf\xe9 na\xefve"},
+		{text: "“Quoted” — it’s…", want: "\x93Quoted\x94 \x97 it\x92s\x85"},
+		{text: "日本 ok", want: "?? ok"},
+		{text: "e\u0301", want: "e"},
+	}
+
+	for _, tt := range tests {
+		if got := string(winAnsi(tt.text)); got != tt.want {
+			t.Errorf("winAnsi(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPDFFontMeasure(t *testing.T) {
+	tests := []struct {
+		font *pdfFont
+		text string
+		want float64
+	}{
+		{font: helvetica, text: "Hello", want: 22.78},
+		{font: helveticaOblique, text: "Hello", want: 22.78},
+		{font: helveticaBold, text: "Hello", want: 24.45},
+		{font: helvetica, text: "é", want: 5.56},
+		{font: helvetica, text: "“”", want: 6.66},
+		{font: helveticaBold, text: "“”", want: 10},
+		{font: helvetica, text: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := pdfNumber(tt.font.measure(tt.text, 10)); got != pdfNumber(tt.want) {
+			t.Errorf("%s.measure(%q, 10) = %s, want %s", tt.font.baseFont, tt.text, got, pdfNumber(tt.want))
+		}
+	}
+}
+
+func TestPDFFontWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width float64
+		want  []string
+	}{
+		{name: "fits", text: "Code is poetry", width: 100, want: []string{"Code is poetry"}},
+		{name: "wraps", text: "Code is poetry", width: 50, want: []string{"Code is", "poetry"}},
+		{name: "long word", text: "Supercalifragilistic is long", width: 25, want: []string{"Supercalifragilistic", "is", "long"}},
+		{name: "line breaks", text: "First\nSecond line", width: 100, want: []string{"First", "Second line"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := helvetica.wrap(tt.text, 10, tt.width)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("wrap() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	got := pdfString(winAnsi(`a (b) \ café`))
+	if want := `(a \(b\) \\ caf\351)`; got != want {
+		t.Errorf("pdfString() = %s, want %s", got, want)
+	}
+}
+
+func TestPDFTextString(t *testing.T) {
+	if got, want := pdfTextString("Café 😀"), "<FEFF00430061006600E90020D83DDE00>"; got != want {
+		t.Errorf("pdfTextString() = %s, want %s", got, want)
+	}
+}
+
+func TestPDFNumber(t *testing.T) {
+	tests := map[float64]string{0: "0", 12: "12", 1.5: "1.5", 22.78: "22.78", 1.0 / 3: "0.33", -0.001: "0", 595.275: "595.27"}
+	for v, want := range tests {
+		if got := pdfNumber(v); got != want {
+			t.Errorf("pdfNumber(%v) = %s, want %s", v, got, want)
+		}
+	}
+}
+
+func TestPDFDocumentBytes(t *testing.T) {
+	doc := newPDFDocument("Test — (1)", 420, 595)
+	doc.addPage().text(helvetica, 12, 50, 500, 0, "First page")
+	doc.addPage().text(helveticaBold, 14, 60, 400, 0.4, "Second page")
+
+	data, err := doc.bytes()
+	if err != nil {
+		t.Fatalf("bytes() unexpected error: %v", err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Errorf("bytes() is missing the PDF header or trailer")
+	}
+	for _, want := range []string{
+		"/Type /Pages /Kids [7 0 R 9 0 R] /Count 2 /MediaBox [0 0 420 595]",
+		"/Title <FEFF0054006500730074002020140020002800310029>",
+		"/BaseFont /Helvetica-Oblique /Encoding /WinAnsiEncoding",
+		"/Font << /F1 4 0 R /F2 5 0 R /F3 6 0 R >>",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("bytes() missing %q", want)
+		}
+	}
+
+	// Every cross-reference entry must point at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if startxref == nil {
+		t.Fatal("bytes() has no startxref")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n0 11\n")) {
+		t.Fatalf("startxref %d doesn't point at an xref table of 11 entries", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(data[xref:], -1)
+	if len(entries) != 10 {
+		t.Fatalf("xref has %d objects, want 10", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if want := strconv.Itoa(i+1) + " 0 obj\n"; !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, data[offset:offset+10], want)
+		}
+	}
+
+	streams := pdfStreams(t, data)
+	want := []string{
+		"BT /F1 12 Tf 0 g 50 500 Td (First page) Tj ET\n",
+		"BT /F3 14 Tf 0.4 g 60 400 Td (Second page) Tj ET\n",
+	}
+	if strings.Join(streams, "|") != strings.Join(want, "|") {
+		t.Errorf("page contents = %q, want %q", streams, want)
+	}
+}
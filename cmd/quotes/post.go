@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// webhookEnv names the environment variable --webhook defaults to
+const webhookEnv = "QUOTES_WEBHOOK_URL"
+
+// webhookTimeout bounds each delivery attempt
+const webhookTimeout = 10 * time.Second
+
+// webhookBackoff is the wait before the first retry, doubling for each one
+// after it up to maxWebhookBackoff
+const (
+	webhookBackoff    = time.Second
+	maxWebhookBackoff = 30 * time.Second
+)
+
+// maxWebhookRetries bounds --retries, which with maxWebhookBackoff keeps a
+// failing delivery from retrying for more than a few minutes
+const maxWebhookRetries = 10
+
+// webhookSnippetLength bounds how much of a failed response is reported
+const webhookSnippetLength = 200
+
+// webhookStyles lists the payload schemas --style accepts
+var webhookStyles = []string{"generic", "mattermost", "slack", "teams"}
+
+var (
+	postWebhookURL string
+	postStyle      string
+	postRetries    int
+	postDryRun     bool
+)
+
+// newPostCommand creates the post subcommand, which publishes quotes to a
+// chat webhook
+func newPostCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "post",
+		Short: "Post quotes to a chat webhook",
+		Long: `Post quotes to an incoming webhook in the payload format the platform expects.
+
+Styles:
+  generic      the JSON array "quotes --format json" prints
+  mattermost   a Markdown message
+  slack        a Block Kit message
+  teams        a message holding an Adaptive Card, for Teams workflows
+
+Quotes are picked like the root command, so --count, --seed, --deck and the
+filter flags work the same way. Deliveries that fail with a network error, a
+429 or a 5xx response are retried with exponential backoff, honouring
+Retry-After. The command exits non-zero if the quotes couldn't be delivered.`,
+		Args: cobra.NoArgs,
+		RunE: runPost,
+	}
+
+	cmd.Flags().StringVar(&postWebhookURL, "webhook", "", "Webhook URL to post to (default $"+webhookEnv+")")
+	cmd.Flags().StringVar(&postStyle, "style", "generic", "Payload style: "+strings.Join(webhookStyles, "|"))
+	cmd.Flags().IntVar(&postRetries, "retries", 3, "Times to retry a failed delivery (0-"+strconv.Itoa(maxWebhookRetries)+")")
+	cmd.Flags().BoolVar(&postDryRun, "dry-run", false, "Print the payload instead of posting it")
+	cmd.Flags().IntVarP(&count, "count", "n", 1, "Number of quotes (1-100)")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Random seed for reproducibility")
+	cmd.Flags().BoolVar(&allowRepeats, "allow-repeats", false, "Allow repeated quotes when count exceeds the collection size")
+	cmd.Flags().BoolVar(&useDeck, "deck", false, "Deal from a persistent shuffled deck so quotes don't repeat across runs")
+
+	return cmd
+}
+
+// runPost picks quotes and delivers them to the webhook
+func runPost(cmd *cobra.Command, args []string) error {
+	// The URL is a secret, so it is read here rather than used as the flag's
+	// default, which cobra would print in the usage text
+	webhookURL := postWebhookURL
+	if webhookURL == "" {
+		webhookURL = os.Getenv(webhookEnv)
+	}
+	if webhookURL == "" && !postDryRun {
+		return fmt.Errorf("--webhook or %s must be set to the webhook URL", webhookEnv)
+	}
+	if postRetries < 0 || postRetries > maxWebhookRetries {
+		return fmt.Errorf("--retries must be between 0 and %d", maxWebhookRetries)
+	}
+
+	// Check the style before dealing, so a typo doesn't use up the deck
+	if _, err := webhookPayload(postStyle, nil); err != nil {
+		return err
+	}
+
+	selected, err := pickQuotes()
+	if err != nil {
+		return err
+	}
+
+	payload, err := webhookPayload(postStyle, selected)
+	if err != nil {
+		return err
+	}
+
+	if postDryRun {
+		fmt.Println(string(payload))
+		return nil
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	return postWebhook(client, webhookURL, payload, postRetries, webhookBackoff)
+}
+
+// webhookPayload renders quotes as the JSON body style's webhooks expect
+func webhookPayload(style string, quotes []Quote) ([]byte, error) {
+	var payload interface{}
+	switch style {
+	case "generic":
+		return []byte(strings.TrimSpace(FormatJSON(quotes))), nil
+	case "mattermost":
+		payload = map[string]string{"text": strings.TrimSpace(FormatMarkdown(quotes))}
+	case "slack":
+		payload = slackWebhookMessage(quotes)
+	case "teams":
+		payload = teamsMessage(quotes)
+	default:
+		return nil, fmt.Errorf("invalid style: %s (must be one of: %s)", style, strings.Join(webhookStyles, ", "))
+	}
+
+	// Keep quote text readable rather than escaping <, > and &
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(payload); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// slackWebhookMessage renders quotes as one Block Kit message, with a
+// divider between quotes
+func slackWebhookMessage(quotes []Quote) map[string]interface{} {
+	var fallback []string
+	blocks := []map[string]interface{}{}
+	for i, q := range quotes {
+		if i > 0 {
+			blocks = append(blocks, map[string]interface{}{"type": "divider"})
+		}
+		blocks = append(blocks, slackBlocks(q)...)
+		fallback = append(fallback, slackFallback(q))
+	}
+	return map[string]interface{}{"text": strings.Join(fallback, "\n"), "blocks": blocks}
+}
+
+// teamsMessage renders quotes as a message carrying an Adaptive Card, the
+// format Teams incoming webhooks and workflows accept
+func teamsMessage(quotes []Quote) map[string]interface{} {
+	body := []map[string]interface{}{}
+	for i, q := range quotes {
+		byline := "— " + q.Author
+		if q.Source != "" {
+			byline += ", _" + q.Source + "_"
+		}
+		if q.Year != 0 {
+			byline += " (" + strconv.Itoa(q.Year) + ")"
+		}
+		if q.URL != "" {
+			byline += " · [Read more](" + q.URL + ")"
+		}
+
+		text := map[string]interface{}{"type": "TextBlock", "text": q.Text, "wrap": true, "size": "Medium"}
+		if i > 0 {
+			text["separator"] = true
+			text["spacing"] = "Large"
+		}
+		body = append(body, text, map[string]interface{}{"type": "TextBlock", "text": byline, "wrap": true, "isSubtle": true, "spacing": "Small"})
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]interface{}{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body":    body,
+				},
+			},
+		},
+	}
+}
+
+// postWebhook posts payload to webhookURL, retrying network errors, 429s and
+// 5xx responses up to retries times. The wait starts at backoff and doubles
+// after each attempt, unless the response sets Retry-After.
+func postWebhook(client *http.Client, webhookURL string, payload []byte, retries int, backoff time.Duration) error {
+	for attempt := 0; ; attempt++ {
+		wait, err := deliverWebhook(client, webhookURL, payload)
+		if err == nil {
+			return nil
+		}
+		if wait < 0 || attempt >= retries {
+			if attempt > 0 {
+				return fmt.Errorf("posting to webhook failed after %d attempts: %w", attempt+1, err)
+			}
+			return fmt.Errorf("posting to webhook failed: %w", err)
+		}
+
+		if wait == 0 {
+			wait = retryBackoff(backoff, attempt)
+		}
+		time.Sleep(min(wait, maxWebhookBackoff))
+	}
+}
+
+// retryBackoff returns backoff doubled once per earlier attempt, capped at
+// maxWebhookBackoff. It stops doubling at the cap, so it can't overflow.
+func retryBackoff(backoff time.Duration, attempt int) time.Duration {
+	wait := backoff
+	for i := 0; i < attempt && wait < maxWebhookBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxWebhookBackoff)
+}
+
+// deliverWebhook makes a single delivery attempt. On failure it returns how
+// long the server asked to wait before retrying (0 if it didn't say), or a
+// negative wait if retrying can't help.
+func deliverWebhook(client *http.Client, webhookURL string, payload []byte) (time.Duration, error) {
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		// The URL is a secret, so drop it from the error and keep only the cause
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookSnippetLength))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+
+	err = fmt.Errorf("%s", resp.Status)
+	if body := strings.TrimSpace(string(snippet)); body != "" {
+		err = fmt.Errorf("%s: %s", resp.Status, body)
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return -1, err
+	}
+
+	var wait time.Duration
+	if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+		wait = time.Duration(min(seconds, int(maxWebhookBackoff/time.Second))) * time.Second
+	}
+	return wait, err
+}
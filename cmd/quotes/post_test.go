@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookPayload(t *testing.T) {
+	quotes := []Quote{
+		{ID: "1", Text: "Simplicity is prerequisite for reliability.", Author: "Edsger Dijkstra", Source: "EWD498", Year: 1975},
+		{ID: "2", Text: "Less is <more>.", Author: "Ludwig Mies van der Rohe", URL: "https://example.com/mies"},
+	}
+
+	tests := []struct {
+		style    string
+		contains []string
+	}{
+		{"generic", []string{`"id": "1"`, `"author": "Ludwig Mies van der Rohe"`}},
+		{"mattermost", []string{`"text":"> Simplicity is prerequisite for reliability.`, "Less is <more>."}},
+		{"slack", []string{`"type":"divider"`, "Less is &lt;more&gt;.", "<https://example.com/mies|Read more>"}},
+		{"teams", []string{`"contentType":"application/vnd.microsoft.card.adaptive"`, `"version":"1.4"`, "_EWD498_ (1975)", "[Read more](https://example.com/mies)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			payload, err := webhookPayload(tt.style, quotes)
+			if err != nil {
+				t.Fatalf("webhookPayload() unexpected error: %v", err)
+			}
+			if !json.Valid(payload) {
+				t.Fatalf("payload is not valid JSON: %s", payload)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(payload), want) {
+					t.Errorf("payload missing %q:\n%s", want, payload)
+				}
+			}
+		})
+	}
+
+	if _, err := webhookPayload("irc", quotes); err == nil {
+		t.Error("webhookPayload() with an unknown style should fail")
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retries      int
+		wantAttempts int
+		wantErr      string
+	}{
+		{"success", []int{http.StatusNoContent}, 3, 1, ""},
+		{"retries server error", []int{http.StatusBadGateway, http.StatusOK}, 3, 2, ""},
+		{"retries rate limit", []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}, 3, 3, ""},
+		{"gives up", []int{http.StatusServiceUnavailable}, 2, 3, "after 3 attempts: 503 Service Unavailable"},
+		{"client error is not retried", []int{http.StatusBadRequest}, 3, 1, "400 Bad Request: invalid payload"},
+		{"no retries", []int{http.StatusInternalServerError}, 0, 1, "posting to webhook failed: 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1)) - 1
+				if got := r.Header.Get("Content-Type"); got != "application/json" {
+					t.Errorf("Content-Type = %q, want application/json", got)
+				}
+				status := tt.statuses[min(n, len(tt.statuses)-1)]
+				w.WriteHeader(status)
+				if status == http.StatusBadRequest {
+					io.WriteString(w, "invalid payload\n")
+				}
+			}))
+			defer srv.Close()
+
+			err := postWebhook(srv.Client(), srv.URL, []byte(`{}`), tt.retries, time.Millisecond)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("postWebhook() unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("postWebhook() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if got := int(attempts.Load()); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestPostWebhook_HidesURL(t *testing.T) {
+	secret := "http://127.0.0.1:1/SECRET-token"
+	client := &http.Client{Timeout: time.Second}
+
+	for _, webhookURL := range []string{secret, "http://[::1/SECRET-token"} {
+		err := postWebhook(client, webhookURL, []byte(`{}`), 0, time.Millisecond)
+		if err == nil {
+			t.Fatalf("postWebhook(%s) succeeded, want an error", webhookURL)
+		}
+		if strings.Contains(err.Error(), "SECRET") {
+			t.Errorf("error shows the webhook URL: %v", err)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		backoff time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{time.Second, 0, time.Second},
+		{time.Second, 1, 2 * time.Second},
+		{time.Second, 4, 16 * time.Second},
+		{time.Second, 5, maxWebhookBackoff},
+		{time.Second, 33, maxWebhookBackoff},
+		{time.Second, 64, maxWebhookBackoff},
+		{time.Millisecond, 3, 8 * time.Millisecond},
+		{time.Minute, 0, maxWebhookBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := retryBackoff(tt.backoff, tt.attempt); got != tt.want {
+			t.Errorf("retryBackoff(%v, %d) = %v, want %v", tt.backoff, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestPostCommand(t *testing.T) {
+	isolateSources(t)
+
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	if _, err := executeCommand(newRootCommand(), "post", "--webhook", srv.URL, "-n", "2", "--seed", "7"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var quotes []Quote
+	if err := json.Unmarshal(received, &quotes); err != nil {
+		t.Fatalf("generic payload is not a JSON array of quotes: %v\n%s", err, received)
+	}
+	if len(quotes) != 2 {
+		t.Errorf("posted %d quotes, want 2", len(quotes))
+	}
+}
+
+func TestPostCommand_WebhookFromEnv(t *testing.T) {
+	isolateSources(t)
+
+	var posted atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted.Store(true)
+	}))
+	defer srv.Close()
+	t.Setenv(webhookEnv, srv.URL)
+
+	if _, err := executeCommand(newRootCommand(), "post", "--seed", "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !posted.Load() {
+		t.Errorf("nothing was posted to $%s", webhookEnv)
+	}
+
+	// The URL is a secret, so it must not appear in the usage text
+	output, err := executeCommand(newRootCommand(), "post", "--help")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(output, srv.URL) {
+		t.Errorf("--help shows the webhook URL:\n%s", output)
+	}
+}
+
+func TestPostCommand_DryRun(t *testing.T) {
+	isolateSources(t)
+
+	output, err := executeCommand(newRootCommand(), "post", "--dry-run", "--style", "teams", "--seed", "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, `"AdaptiveCard"`) {
+		t.Errorf("expected an Adaptive Card payload, got:\n%s", output)
+	}
+}
+
+func TestPostCommand_Errors(t *testing.T) {
+	isolateSources(t)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"missing webhook", []string{"post"}, "--webhook"},
+		{"invalid style", []string{"post", "--dry-run", "--style", "irc"}, "invalid style"},
+		{"negative retries", []string{"post", "--webhook", "http://localhost", "--retries", "-1"}, "--retries must be between 0 and 10"},
+		{"too many retries", []string{"post", "--webhook", "http://localhost", "--retries", "11"}, "--retries must be between 0 and 10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executeCommand(newRootCommand(), tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
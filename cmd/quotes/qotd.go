@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// qotdMaxLength is the RFC 865 limit on the length of a quote
+const qotdMaxLength = 512
+
+var (
+	qotdHost      string
+	qotdPort      int
+	qotdRateLimit int
+)
+
+// newQotdCommand creates the serve-qotd subcommand, an RFC 865 Quote of the
+// Day server
+func newQotdCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve-qotd",
+		Short: "Serve quotes over the RFC 865 Quote of the Day protocol",
+		Long: `Serve the Quote of the Day protocol (RFC 865) on TCP and UDP.
+
+Each TCP connection receives one random quote and is then closed; each UDP
+datagram is answered with one random quote. Quotes are formatted as text and
+cut to the protocol's 512 character limit.
+
+Clients that exceed --rate-limit are dropped without a reply, which also stops
+the UDP service being used to flood spoofed addresses.
+
+SIGTERM or SIGINT stops the server, and SIGHUP reloads the collection.`,
+		Args: cobra.NoArgs,
+		RunE: runQotd,
+	}
+
+	cmd.Flags().StringVar(&qotdHost, "host", "", "Address to listen on (default all interfaces)")
+	cmd.Flags().IntVar(&qotdPort, "port", 17, "Port to listen on; ports below 1024 usually need root")
+	cmd.Flags().IntVar(&qotdRateLimit, "rate-limit", 10, "Quotes per minute each client address may receive (0 for no limit)")
+
+	return cmd
+}
+
+// runQotd serves QOTD on TCP and UDP until SIGTERM or SIGINT
+func runQotd(cmd *cobra.Command, args []string) error {
+	if qotdPort < 0 || qotdPort > 65535 {
+		return fmt.Errorf("--port must be 0-65535, got %d", qotdPort)
+	}
+	if qotdRateLimit < 0 {
+		return fmt.Errorf("--rate-limit must not be negative")
+	}
+
+	store, err := newQuoteStore(loadFilteredQuotes)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(qotdHost, strconv.Itoa(qotdPort))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return listenError(err)
+	}
+	defer listener.Close()
+
+	// Bind UDP to the port TCP got, which matters when --port is 0
+	udpAddr := net.JoinHostPort(qotdHost, strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+	conn, err := net.ListenPacket("udp", udpAddr)
+	if err != nil {
+		return listenError(err)
+	}
+	defer conn.Close()
+
+	srv := &qotdServer{store: store, limiter: newRateLimiter(qotdRateLimit, time.Minute)}
+
+	log.Printf("serving QOTD for %d quotes on %s (tcp and udp)", len(store.Collection().quotes), listener.Addr())
+	return runUntilSignalled(store,
+		func() error { return srv.serveTCP(listener) },
+		func() error { return srv.serveUDP(conn) },
+	)
+}
+
+// qotdServer answers QOTD requests with quotes from store
+type qotdServer struct {
+	store   *quoteStore
+	limiter *rateLimiter
+}
+
+// quote returns a random quote formatted for the wire
+func (s *qotdServer) quote() []byte {
+	q, err := SelectRandom(s.store.Collection().quotes, time.Now().UnixNano())
+	if err != nil {
+		return nil
+	}
+	return []byte(truncateQuote(FormatText([]Quote{q}), qotdMaxLength))
+}
+
+// serveTCP sends one quote to each connection on listener until it is
+// closed
+func (s *qotdServer) serveTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+			if !s.limiter.Allow(hostOf(conn.RemoteAddr())) {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(requestTimeout))
+			conn.Write(s.quote())
+		}()
+	}
+}
+
+// serveUDP answers each datagram on conn with one quote until it is closed.
+// The datagram's content is ignored, as RFC 865 specifies.
+func (s *qotdServer) serveUDP(conn net.PacketConn) error {
+	buf := make([]byte, 512)
+	for {
+		_, addr, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !s.limiter.Allow(hostOf(addr)) {
+			continue
+		}
+		conn.WriteTo(s.quote(), addr)
+	}
+}
+
+// truncateQuote cuts s to at most max bytes without splitting a character,
+// ending a shortened quote with "..."
+func truncateQuote(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	const ellipsis = "...\n"
+	cut := max - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + ellipsis
+}
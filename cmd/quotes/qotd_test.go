@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestTruncateQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{name: "short", in: "Hello\n", max: 10, want: "Hello\n"},
+		{name: "exact", in: "0123456789", max: 10, want: "0123456789"},
+		{name: "long", in: "0123456789abc", max: 10, want: "012345...\n"},
+		{name: "multibyte boundary", in: "aaaaaé0123456", max: 10, want: "aaaaa...\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateQuote(tt.in, tt.max)
+			if got != tt.want {
+				t.Errorf("truncateQuote() = %q, want %q", got, tt.want)
+			}
+			if len(got) > tt.max || !utf8.ValidString(got) {
+				t.Errorf("truncateQuote() = %q is too long or not valid UTF-8", got)
+			}
+		})
+	}
+}
+
+// newTestQotdServer serves the built-in quotes with the given rate limit
+func newTestQotdServer(t *testing.T, limit int) *qotdServer {
+	t.Helper()
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return withIDs(defaultQuotes), nil
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+	return &qotdServer{store: store, limiter: newRateLimiter(limit, time.Minute)}
+}
+
+func TestQotdServer_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := newTestQotdServer(t, 1)
+	done := make(chan error, 1)
+	go func() { done <- srv.serveTCP(listener) }()
+
+	read := func() string {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		data, err := io.ReadAll(conn)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return string(data)
+	}
+
+	quote := read()
+	if !strings.Contains(quote, "   - ") || len(quote) > qotdMaxLength {
+		t.Errorf("unexpected quote %q", quote)
+	}
+	if got := read(); got != "" {
+		t.Errorf("rate-limited client got %q, want nothing", got)
+	}
+
+	listener.Close()
+	if err := <-done; err != nil {
+		t.Errorf("serveTCP() error = %v, want nil after close", err)
+	}
+}
+
+func TestQotdServer_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := newTestQotdServer(t, 1)
+	done := make(chan error, 1)
+	go func() { done <- srv.serveUDP(conn) }()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	client.Write([]byte("\n"))
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(buf[:n]), "   - ") || n > qotdMaxLength {
+		t.Errorf("unexpected quote %q", buf[:n])
+	}
+
+	// The second datagram is over the limit and gets no reply
+	client.Write([]byte("\n"))
+	client.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, err := client.Read(buf); err == nil {
+		t.Errorf("rate-limited client got %q, want nothing", buf[:n])
+	}
+
+	conn.Close()
+	if err := <-done; err != nil {
+		t.Errorf("serveUDP() error = %v, want nil after close", err)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// sayCharacter is the --character flag
+var sayCharacter string
+
+// defaultSayWidth is how wide speech bubbles are without --width
+const defaultSayWidth = 40
+
+// characterExt is the extension of character files in the characters directory
+const characterExt = ".cow"
+
+// characters holds the built-in --character art, written like the body of a
+// cowsay .cow file: backslashes are escaped, and $thoughts, $eyes and $tongue
+// are filled in when drawn
+var characters = map[string]string{
+	"cow": `        $thoughts   ^__^
+         $thoughts  ($eyes)\\_______
+            (__)\\       )\\/\\
+             $tongue ||----w |
+                ||     ||
+`,
+	"gopher": `   $thoughts
+    $thoughts  ,-.       ,-.
+       ( (  .-"""-.  ) )
+        ` + "`" + `-./ O   O \\.-'
+           |   ^   |
+           |  [|]  |
+           \\       /
+            ` + "`" + `-...-'
+`,
+	"tux": `   $thoughts
+    $thoughts
+        .--.
+       |o_o |
+       |:_/ |
+      //   \\ \\
+     (|     | )
+    /'\\_   _/` + "`" + `\\
+    \\___)=(___/
+`,
+}
+
+// characterVariables are the values drawn in place of a character's variables
+var characterVariables = map[string]string{
+	"thoughts": `\`,
+	"eyes":     "oo",
+	"tongue":   "  ",
+}
+
+// cowHeredoc matches the start of the art in a cowsay .cow file, such as
+// `$the_cow = <<"EOC";`
+var cowHeredoc = regexp.MustCompile(`<<\s*(["']?)(\w+)["']?`)
+
+// addSayFlags registers --character on a command that prints quotes
+func addSayFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&sayCharacter, "character", "cow", "Character speaking with --format say: "+strings.Join(styleNames(characters), "|")+", or a .cow file name")
+}
+
+// charactersDir returns the directory custom characters are loaded from
+func charactersDir() (string, error) {
+	dir, err := quotesConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "characters"), nil
+}
+
+// loadCharacter returns the drawn art of the named character. A file named
+// name.cow in the characters directory takes precedence over a built-in
+// character of the same name.
+func loadCharacter(name string) (string, error) {
+	if !isPlainName(name) {
+		return "", fmt.Errorf("invalid character name: %q", name)
+	}
+
+	dir, err := charactersDir()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+characterExt))
+	if err == nil {
+		art := parseCowFile(string(data))
+		if !strings.HasSuffix(art, "\n") {
+			art += "\n"
+		}
+		return art, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	if art, ok := characters[name]; ok {
+		return drawCharacter(art, true), nil
+	}
+
+	available := styleNames(characters)
+	for _, saved := range savedNames(dir, characterExt) {
+		if _, ok := characters[saved]; !ok {
+			available = append(available, saved)
+		}
+	}
+	sort.Strings(available)
+	return "", fmt.Errorf("unknown character: %s (available: %s)", name, strings.Join(available, ", "))
+}
+
+// parseCowFile draws the art in a cowsay .cow file. The art is the body of
+// the file's heredoc, with Perl escapes unless the heredoc is single-quoted.
+// Files without a heredoc are taken as the art itself.
+func parseCowFile(data string) string {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for i, line := range lines {
+		match := cowHeredoc.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		var art strings.Builder
+		for _, line := range lines[i+1:] {
+			if line == match[2] {
+				break
+			}
+			art.WriteString(line + "\n")
+		}
+		return drawCharacter(art.String(), match[1] != "'")
+	}
+
+	return drawCharacter(data, false)
+}
+
+// drawCharacter fills in the variables of character art. With escapes, a
+// backslash makes the character after it literal, as in a Perl string.
+func drawCharacter(art string, escapes bool) string {
+	var result strings.Builder
+
+	for i := 0; i < len(art); i++ {
+		switch c := art[i]; {
+		case escapes && c == '\\' && i+1 < len(art):
+			i++
+			result.WriteByte(art[i])
+		case c == '$':
+			name := art[i+1:]
+			if end := strings.IndexFunc(name, func(r rune) bool { return r != '_' && !isASCIILetter(r) }); end >= 0 {
+				name = name[:end]
+			}
+			if value, ok := characterVariables[name]; ok {
+				result.WriteString(value)
+				i += len(name)
+				continue
+			}
+			result.WriteByte(c)
+		default:
+			result.WriteByte(c)
+		}
+	}
+
+	return result.String()
+}
+
+// isASCIILetter reports whether r is an ASCII letter
+func isASCIILetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// FormatSay formats each quote as a speech bubble, wrapped to width columns,
+// spoken by the drawn character art
+func FormatSay(quotes []Quote, art string, width int) string {
+	var result strings.Builder
+
+	for i, q := range quotes {
+		if i > 0 {
+			result.WriteString("\n")
+		}
+		lines := append(wrapLines(width, q.Text), wrapLines(width, "— "+attribution(q, q.Source))...)
+		result.WriteString(speechBubble(lines))
+		result.WriteString(art)
+	}
+
+	return result.String()
+}
+
+// speechBubble draws a cowsay-style bubble around lines, sized by their
+// display width
+func speechBubble(lines []string) string {
+	width := 0
+	for _, line := range lines {
+		width = max(width, displayWidth(line))
+	}
+
+	var result strings.Builder
+	result.WriteString(" " + strings.Repeat("_", width+2) + "\n")
+	for i, line := range lines {
+		left, right := "|", "|"
+		switch {
+		case len(lines) == 1:
+			left, right = "<", ">"
+		case i == 0:
+			left, right = "/", `\`
+		case i == len(lines)-1:
+			left, right = `\`, "/"
+		}
+		padding := strings.Repeat(" ", width-displayWidth(line))
+		fmt.Fprintf(&result, "%s %s%s %s\n", left, line, padding, right)
+	}
+	result.WriteString(" " + strings.Repeat("-", width+2) + "\n")
+
+	return result.String()
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpeechBubble(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{
+			name:  "one line",
+			lines: []string{"Code is poetry"},
+			want:  " ________________\n< Code is poetry >\n ----------------\n",
+		},
+		{
+			name:  "two lines",
+			lines: []string{"Code is poetry", "— Unknown"},
+			want:  " ________________\n/ Code is poetry \\\n\\ — Unknown      /\n ----------------\n",
+		},
+		{
+			name:  "wide characters",
+			lines: []string{"日本語", "— 作者", "abc"},
+			want:  " ________\n/ 日本語 \\\n| — 作者 |\n\\ abc    /\n --------\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := speechBubble(tt.lines); got != tt.want {
+				t.Errorf("speechBubble() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCowFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "heredoc",
+			data: "# A cow\n$the_cow = <<\"EOC\";\n  $thoughts\n   ($eyes)\\\\_ \\$5\nEOC\n",
+			want: "  \\\n   (oo)\\_ $5\n",
+		},
+		{
+			name: "unquoted heredoc",
+			data: "$the_cow = <<EOC;\n $thoughts $tongue|\nEOC\n",
+			want: " \\   |\n",
+		},
+		{
+			name: "single-quoted heredoc keeps backslashes",
+			data: "$the_cow = <<'EOC';\n $thoughts /\\\nEOC\n",
+			want: " \\ /\\\n",
+		},
+		{
+			name: "plain art",
+			data: " $thoughts\n  (\\_/)\n $price\n",
+			want: " \\\n  (\\_/)\n $price\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCowFile(tt.data); got != tt.want {
+				t.Errorf("parseCowFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCharacter(t *testing.T) {
+	home := isolateSources(t)
+	dir := filepath.Join(home, "config", "quotes", "characters")
+	writeQuotes(t, filepath.Join(dir, "bunny.cow"), "$the_cow = <<EOC;\n $thoughts\n  (\\\\_/)\nEOC\n")
+	writeQuotes(t, filepath.Join(dir, "tux.cow"), " $thoughts\n  my tux\n")
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "cow", want: "(oo)\\_______"},
+		{name: "gopher", want: "O   O"},
+		{name: "bunny", want: " \\\n  (\\_/)\n"},
+		{name: "tux", want: "my tux"},
+		{name: "dragon", wantErr: "available: bunny, cow, gopher, tux)"},
+		{name: "../bunny", wantErr: "invalid character name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadCharacter(tt.name)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadCharacter() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadCharacter() unexpected error: %v", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("loadCharacter() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSay(t *testing.T) {
+	quotes := []Quote{
+		{Text: "Talk is cheap. Show me the code.", Author: "Linus Torvalds"},
+		{Text: "Code is poetry", Author: "Unknown"},
+	}
+
+	got := FormatSay(quotes, "  \\\n   art\n", 20)
+	want := " _____________________\n" +
+		"/ Talk is cheap. Show \\\n" +
+		"| me the code.        |\n" +
+		"\\ — Linus Torvalds    /\n" +
+		" ---------------------\n" +
+		"  \\\n   art\n" +
+		"\n" +
+		" ________________\n" +
+		"/ Code is poetry \\\n" +
+		"\\ — Unknown      /\n" +
+		" ----------------\n" +
+		"  \\\n   art\n"
+	if got != want {
+		t.Errorf("FormatSay() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSayCommand(t *testing.T) {
+	isolateSources(t)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "cow by default",
+			args: []string{"--format", "say", "--author", "dijkstra", "--seed", "1"},
+			want: []string{"— Edsger Dijkstra", "(oo)"},
+		},
+		{
+			name: "character and width",
+			args: []string{"today", "--format", "say", "--character", "tux", "--width", "20", "--author", "cook"},
+			want: []string{"/ Programming today is \\", "|o_o |"},
+		},
+		{
+			name: "search",
+			args: []string{"search", "--format", "say", "--character", "gopher", "--limit", "1", "code"},
+			want: []string{"O   O"},
+		},
+		{
+			name:    "character needs say",
+			args:    []string{"--character", "tux"},
+			wantErr: "--character needs --format say",
+		},
+		{
+			name:    "unknown character",
+			args:    []string{"--format", "say", "--character", "dragon"},
+			wantErr: "unknown character",
+		},
+		{
+			name:    "search score",
+			args:    []string{"search", "--format", "say", "--score", "code"},
+			wantErr: "--score",
+		},
+		{
+			name:    "list fields",
+			args:    []string{"list", "--format", "say", "--fields", "id"},
+			wantErr: "--fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := executeCommand(newRootCommand(), tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// maxScheduleSleep bounds how long the scheduler waits before checking the
+// clock again, so it notices a suspended machine or a changed system clock
+const maxScheduleSleep = time.Minute
+
+// Policies for runs missed while the scheduler wasn't running
+const (
+	missedCatchUp = "catch-up"
+	missedSkip    = "skip"
+)
+
+var (
+	scheduleConfigPath string
+	scheduleStatePath  string
+	scheduleList       bool
+)
+
+// scheduleConfig is the schedule file
+type scheduleConfig struct {
+	// Timezone is the IANA time zone the cron expressions are read in,
+	// defaulting to the local one
+	Timezone string         `json:"timezone"`
+	Jobs     []*scheduleJob `json:"jobs"`
+
+	location *time.Location
+}
+
+// scheduleJob is a recurring delivery of quotes
+type scheduleJob struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+
+	// Missed is missedCatchUp or missedSkip
+	Missed string `json:"missed"`
+
+	// Selection, with the filters named as in the HTTP API
+	Count       int    `json:"count"`
+	Format      string `json:"format"`
+	Deck        bool   `json:"deck"`
+	Author      string `json:"author"`
+	ExactAuthor bool   `json:"exact_author"`
+	MinLength   int    `json:"min_length"`
+	MaxLength   int    `json:"max_length"`
+	Match       string `json:"match"`
+	Tag         string `json:"tag"`
+
+	Destination scheduleDestination `json:"destination"`
+
+	cron   *cronSchedule
+	filter Filter
+}
+
+// scheduleDestination is where a job delivers its quotes
+type scheduleDestination struct {
+	// Type is stdout, file, webhook or smtp
+	Type string `json:"type"`
+
+	// file
+	Path   string `json:"path"`
+	Append bool   `json:"append"`
+
+	// webhook
+	URL   string `json:"url"`
+	Style string `json:"style"`
+
+	// smtp
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Subject  string   `json:"subject"`
+	Insecure bool     `json:"insecure"`
+}
+
+// scheduleState is the persisted record of when each job last ran
+type scheduleState struct {
+	LastRun map[string]time.Time `json:"last_run"`
+}
+
+// newScheduleCommand creates the schedule subcommand, which delivers quotes
+// on cron schedules
+func newScheduleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Deliver quotes on cron schedules",
+		Long: `Run until stopped, delivering quotes for each job in a schedule file when its
+cron expression comes due.
+
+Each job picks quotes with its own count, format, deck and filters, and sends
+them to stdout, a file, a chat webhook (as "quotes post" does) or an SMTP
+server. The time of each job's last successful run is saved, so after
+downtime a job with "missed": "catch-up" runs once straight away, while one
+with "missed": "skip" waits for its next time. Jobs run independently, so a
+slow delivery doesn't hold up the others; a job still running when it comes
+due again skips that run.
+
+SMTP jobs send the digest "quotes mail" sends, with its plain-text part in the
+job's format. Like "quotes mail", they require the server to offer STARTTLS,
+unless the destination sets "insecure": true for a local relay.
+
+The schedule file defaults to schedule/config.json in the quotes config
+directory.
+SIGTERM or SIGINT stops the scheduler once running jobs finish, and SIGHUP
+reloads the collection.`,
+		Args: cobra.NoArgs,
+		RunE: runSchedule,
+	}
+
+	cmd.Flags().StringVar(&scheduleConfigPath, "config", "", "Schedule file (default schedule/config.json in the quotes config directory)")
+	cmd.Flags().StringVar(&scheduleStatePath, "state", "", "File recording when jobs last ran (default schedule.json in the quotes state directory)")
+	cmd.Flags().BoolVar(&scheduleList, "list", false, "Print each job's next run time and exit")
+
+	return cmd
+}
+
+// defaultSchedulePath returns the schedule file used without --config. It
+// sits in a subdirectory so the quote files chain doesn't load it as quotes.
+func defaultSchedulePath() (string, error) {
+	dir, err := quotesConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "schedule", "config.json"), nil
+}
+
+// runSchedule runs the scheduled jobs until SIGTERM or SIGINT
+func runSchedule(cmd *cobra.Command, args []string) error {
+	configPath := scheduleConfigPath
+	if configPath == "" {
+		path, err := defaultSchedulePath()
+		if err != nil {
+			return err
+		}
+		configPath = path
+	}
+
+	config, err := loadScheduleConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	statePath := scheduleStatePath
+	if statePath == "" {
+		dir, err := quotesStateDir()
+		if err != nil {
+			return err
+		}
+		statePath = filepath.Join(dir, "schedule.json")
+	}
+
+	store, err := newQuoteStore(loadFilteredQuotes)
+	if err != nil {
+		return err
+	}
+
+	s, err := newScheduler(config, store, statePath, now())
+	if err != nil {
+		return err
+	}
+
+	if scheduleList {
+		for _, job := range config.Jobs {
+			fmt.Printf("%s\t%s\t%s\n", job.Name, job.Schedule, s.next[job.Name].Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	log.Printf("scheduling %d jobs with %d quotes", len(config.Jobs), len(store.Collection().quotes))
+	for {
+		wake := s.runDue(now())
+
+		timer := time.NewTimer(min(time.Until(wake), maxScheduleSleep))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Printf("shutting down")
+			s.wait()
+			return nil
+		case <-reload:
+			reloadStore(store)
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// loadScheduleConfig reads and checks the schedule file at path, filling in
+// defaults
+func loadScheduleConfig(path string) (*scheduleConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config scheduleConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	config.location = time.Local
+	if config.Timezone != "" {
+		if config.location, err = time.LoadLocation(config.Timezone); err != nil {
+			return nil, fmt.Errorf("%s: invalid timezone: %w", path, err)
+		}
+	}
+
+	if len(config.Jobs) == 0 {
+		return nil, fmt.Errorf("%s: no jobs", path)
+	}
+
+	names := make(map[string]bool)
+	for i, job := range config.Jobs {
+		if job == nil || job.Name == "" {
+			return nil, fmt.Errorf("%s: job %d: missing \"name\"", path, i+1)
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("%s: job %q: duplicate name", path, job.Name)
+		}
+		names[job.Name] = true
+
+		if err := job.check(); err != nil {
+			return nil, fmt.Errorf("%s: job %q: %w", path, job.Name, err)
+		}
+	}
+
+	return &config, nil
+}
+
+// check validates the job and fills in its defaults
+func (j *scheduleJob) check() error {
+	var err error
+	if j.cron, err = parseCron(j.Schedule); err != nil {
+		return err
+	}
+
+	switch j.Missed {
+	case "":
+		j.Missed = missedCatchUp
+	case missedCatchUp, missedSkip:
+	default:
+		return fmt.Errorf("invalid missed policy: %s (must be %s or %s)", j.Missed, missedCatchUp, missedSkip)
+	}
+
+	if j.Count == 0 {
+		j.Count = 1
+	}
+	if j.Count < 1 || j.Count > 100 {
+		return fmt.Errorf("count must be 1-100, got %d", j.Count)
+	}
+
+	if j.Format == "" {
+		j.Format = "text"
+	}
+	if !isValidFormat(j.Format) {
+		return fmt.Errorf("invalid format: %s (must be one of: text, json, markdown, html, say)", j.Format)
+	}
+
+	j.filter = Filter{Author: j.Author, ExactAuthor: j.ExactAuthor, MinLength: j.MinLength, MaxLength: j.MaxLength, Tag: j.Tag}
+	if j.Match != "" {
+		if j.filter.Match, err = regexp.Compile(j.Match); err != nil {
+			return fmt.Errorf("invalid match pattern: %w", err)
+		}
+	}
+
+	return j.Destination.check()
+}
+
+// check validates the destination and fills in its defaults
+func (d *scheduleDestination) check() error {
+	switch d.Type {
+	case "", "stdout":
+		d.Type = "stdout"
+	case "file":
+		if d.Path == "" {
+			return fmt.Errorf("file destination needs a \"path\"")
+		}
+	case "webhook":
+		if d.URL == "" {
+			return fmt.Errorf("webhook destination needs a \"url\"")
+		}
+		if d.Style == "" {
+			d.Style = "generic"
+		}
+		if _, err := webhookPayload(d.Style, nil); err != nil {
+			return err
+		}
+	case "smtp":
+		if d.Host == "" || d.From == "" || len(d.To) == 0 {
+			return fmt.Errorf("smtp destination needs \"host\", \"from\" and \"to\"")
+		}
+		if d.Port == 0 {
+			d.Port = 587
+		}
+		if d.Subject == "" {
+			d.Subject = defaultMailSubject
+		}
+		if _, err := parseTemplate("subject", d.Subject); err != nil {
+			return fmt.Errorf("invalid subject template: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid destination type: %s (must be one of: stdout, file, webhook, smtp)", d.Type)
+	}
+	return nil
+}
+
+// scheduler runs the jobs of a schedule as they come due
+type scheduler struct {
+	jobs      []*scheduleJob
+	location  *time.Location
+	store     *quoteStore
+	statePath string
+
+	// next is when each job, by name, runs next
+	next map[string]time.Time
+
+	// mu guards state and running, which job goroutines update
+	mu    sync.Mutex
+	state scheduleState
+	// running holds the names of jobs whose last run hasn't finished
+	running map[string]bool
+	// inFlight tracks job runs, so shutdown can wait for them
+	inFlight sync.WaitGroup
+
+	// out receives stdout deliveries
+	out io.Writer
+}
+
+// newScheduler plans each job's next run from the state at statePath,
+// applying the job's missed policy to runs that fell due before now
+func newScheduler(config *scheduleConfig, store *quoteStore, statePath string, now time.Time) (*scheduler, error) {
+	s := &scheduler{
+		jobs:      config.Jobs,
+		location:  config.location,
+		store:     store,
+		statePath: statePath,
+		next:      make(map[string]time.Time),
+		running:   make(map[string]bool),
+		out:       os.Stdout,
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(data) > 0 && json.Unmarshal(data, &s.state) != nil {
+		log.Printf("ignoring damaged schedule state %s", statePath)
+	}
+	if s.state.LastRun == nil {
+		s.state.LastRun = make(map[string]time.Time)
+	}
+
+	now = now.In(s.location)
+	for _, job := range s.jobs {
+		next := job.cron.Next(now)
+		if last, ok := s.state.LastRun[job.Name]; ok {
+			if missed := job.cron.Next(last.In(s.location)); !missed.IsZero() && !missed.After(now) {
+				if job.Missed == missedCatchUp {
+					log.Printf("job %q: catching up on the run due at %s", job.Name, missed.Format(time.RFC3339))
+					next = missed
+				} else {
+					log.Printf("job %q: skipping runs missed since %s", job.Name, missed.Format(time.RFC3339))
+				}
+			}
+		}
+
+		if next.IsZero() {
+			return nil, fmt.Errorf("job %q: schedule %q never comes due", job.Name, job.Schedule)
+		}
+		s.next[job.Name] = next
+	}
+
+	return s, nil
+}
+
+// runDue starts every job due at or before now and returns when the next one
+// is due. Jobs run in their own goroutines, so a slow delivery doesn't hold
+// up the others, and a job still running from its last time is skipped.
+// Each job then waits for its first time after now, so several missed runs
+// only deliver once.
+func (s *scheduler) runDue(now time.Time) time.Time {
+	now = now.In(s.location)
+
+	var wake time.Time
+	for _, job := range s.jobs {
+		if due := s.next[job.Name]; !due.After(now) {
+			s.start(job, due, now)
+			s.next[job.Name] = job.cron.Next(now)
+		}
+
+		if next := s.next[job.Name]; !next.IsZero() && (wake.IsZero() || next.Before(wake)) {
+			wake = next
+		}
+	}
+	return wake
+}
+
+// start runs job in a goroutine, unless its previous run is still going
+func (s *scheduler) start(job *scheduleJob, due, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[job.Name] {
+		log.Printf("job %q: skipping the run due at %s, the last one is still running", job.Name, due.Format(time.RFC3339))
+		return
+	}
+	s.running[job.Name] = true
+
+	s.inFlight.Add(1)
+	go func() {
+		defer s.inFlight.Done()
+
+		if err := s.run(job, due); err != nil {
+			log.Printf("job %q: %v", job.Name, err)
+		} else if err := s.recordRun(job, now); err != nil {
+			log.Printf("job %q: cannot save schedule state: %v", job.Name, err)
+		}
+
+		s.mu.Lock()
+		delete(s.running, job.Name)
+		s.mu.Unlock()
+	}()
+}
+
+// wait waits for every job run in flight to finish
+func (s *scheduler) wait() {
+	s.inFlight.Wait()
+}
+
+// run selects quotes for the run of job due at due and delivers them
+func (s *scheduler) run(job *scheduleJob, due time.Time) error {
+	quotes, err := FilterQuotes(s.store.Collection().quotes, job.filter)
+	if err != nil {
+		return err
+	}
+
+	// Seed from the job and the time it was due, so a run picks the same
+	// quotes however late it starts
+	h := fnv.New64a()
+	io.WriteString(h, job.Name)
+	seed := due.Unix() ^ int64(h.Sum64())
+
+	var selected []Quote
+	if job.Deck {
+		if job.Count > len(quotes) {
+			return fmt.Errorf("%w: requested %d but only %d available", ErrNotEnoughQuotes, job.Count, len(quotes))
+		}
+		selected, err = DealFromDeck(s.deckPath(job), quotes, job.Count, seed)
+	} else {
+		selected, err = SelectQuotes(quotes, job.Count, seed, false)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.deliver(job, selected)
+}
+
+// deckPath returns the deck state file for job, kept beside the schedule
+// state so each job deals through its own deck
+func (s *scheduler) deckPath(job *scheduleJob) string {
+	sum := sha256.Sum256([]byte(job.Name))
+	return filepath.Join(filepath.Dir(s.statePath), "deck-job-"+hex.EncodeToString(sum[:])[:16]+".json")
+}
+
+// deliver sends quotes to job's destination
+func (s *scheduler) deliver(job *scheduleJob, quotes []Quote) error {
+	d := job.Destination
+	switch d.Type {
+	case "file":
+		return writeQuotesFile(d.Path, formatQuotes(job.Format, quotes), d.Append)
+	case "webhook":
+		payload, err := webhookPayload(d.Style, quotes)
+		if err != nil {
+			return err
+		}
+		client := &http.Client{Timeout: webhookTimeout}
+		return postWebhook(client, os.ExpandEnv(d.URL), payload, 3, webhookBackoff)
+	case "smtp":
+		msg, err := mailDigest(d.From, d.To, d.Subject, quotes, formatQuotes(job.Format, quotes), now())
+		if err != nil {
+			return err
+		}
+		server := mailServer{host: d.Host, port: d.Port, username: d.Username, password: os.ExpandEnv(d.Password), insecure: d.Insecure}
+		err = sendMail(server, d.From, d.To, msg)
+		if errors.Is(err, errNoStartTLS) {
+			return errors.New(`SMTP server does not support STARTTLS (set "insecure": true to send without it)`)
+		}
+		return err
+	default:
+		_, err := io.WriteString(s.out, formatQuotes(job.Format, quotes))
+		return err
+	}
+}
+
+// recordRun saves now as job's last run
+func (s *scheduler) recordRun(job *scheduleJob, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.LastRun[job.Name] = now
+
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.statePath, append(data, '\n'))
+}
+
+// writeQuotesFile appends text to the file at path, or replaces the file
+// in one step so readers never see it half written
+func writeQuotesFile(path, text string, appendText bool) error {
+	if !appendText {
+		return writeFileAtomic(path, []byte(text))
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeFileAtomic replaces the file at path with data by writing a
+// temporary file beside it and renaming it into place. Each write gets its
+// own temporary file, so concurrent writers to one path don't collide.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
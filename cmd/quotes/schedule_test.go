@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestScheduler loads the schedule config from content and plans it at
+// now, over the built-in quotes with state kept in a temporary directory
+func newTestScheduler(t *testing.T, content string, state *scheduleState, now time.Time) (*scheduler, *bytes.Buffer) {
+	t.Helper()
+	dir := t.TempDir()
+
+	configPath := filepath.Join(dir, "schedule.json")
+	writeQuotes(t, configPath, content)
+	config, err := loadScheduleConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadScheduleConfig() unexpected error: %v", err)
+	}
+
+	statePath := filepath.Join(dir, "state", "schedule.json")
+	if state != nil {
+		data, _ := json.Marshal(state)
+		os.MkdirAll(filepath.Dir(statePath), 0755)
+		os.WriteFile(statePath, data, 0644)
+	}
+
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return loadCollection(nil, true)
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+
+	s, err := newScheduler(config, store, statePath, now)
+	if err != nil {
+		t.Fatalf("newScheduler() unexpected error: %v", err)
+	}
+
+	var out bytes.Buffer
+	s.out = &out
+	return s, &out
+}
+
+func TestLoadScheduleConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	writeQuotes(t, path, `{"timezone": "UTC", "jobs": [{"name": "morning", "schedule": "0 9 * * *"}]}`)
+
+	config, err := loadScheduleConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	job := config.Jobs[0]
+	if job.Missed != missedCatchUp || job.Count != 1 || job.Format != "text" || job.Destination.Type != "stdout" {
+		t.Errorf("defaults not applied: missed=%q count=%d format=%q destination=%q", job.Missed, job.Count, job.Format, job.Destination.Type)
+	}
+}
+
+func TestLoadScheduleConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"invalid JSON", `{"jobs": [`, "unexpected EOF"},
+		{"unknown field", `{"jobs": [{"name": "a", "schedule": "@daily", "colour": "red"}]}`, "unknown field"},
+		{"no jobs", `{"jobs": []}`, "no jobs"},
+		{"bad timezone", `{"timezone": "Mars/Olympus", "jobs": [{"name": "a", "schedule": "@daily"}]}`, "invalid timezone"},
+		{"missing name", `{"jobs": [{"schedule": "@daily"}]}`, `job 1: missing "name"`},
+		{"duplicate name", `{"jobs": [{"name": "a", "schedule": "@daily"}, {"name": "a", "schedule": "@hourly"}]}`, "duplicate name"},
+		{"bad schedule", `{"jobs": [{"name": "a", "schedule": "61 * * * *"}]}`, "invalid minute"},
+		{"bad missed policy", `{"jobs": [{"name": "a", "schedule": "@daily", "missed": "later"}]}`, "invalid missed policy"},
+		{"bad count", `{"jobs": [{"name": "a", "schedule": "@daily", "count": 101}]}`, "count must be 1-100"},
+		{"bad format", `{"jobs": [{"name": "a", "schedule": "@daily", "format": "xml"}]}`, "invalid format"},
+		{"bad match", `{"jobs": [{"name": "a", "schedule": "@daily", "match": "("}]}`, "invalid match pattern"},
+		{"bad destination", `{"jobs": [{"name": "a", "schedule": "@daily", "destination": {"type": "fax"}}]}`, "invalid destination type"},
+		{"file without path", `{"jobs": [{"name": "a", "schedule": "@daily", "destination": {"type": "file"}}]}`, `needs a "path"`},
+		{"webhook without url", `{"jobs": [{"name": "a", "schedule": "@daily", "destination": {"type": "webhook"}}]}`, `needs a "url"`},
+		{"bad webhook style", `{"jobs": [{"name": "a", "schedule": "@daily", "destination": {"type": "webhook", "url": "http://x", "style": "irc"}}]}`, "invalid style"},
+		{"incomplete smtp", `{"jobs": [{"name": "a", "schedule": "@daily", "destination": {"type": "smtp", "host": "mail"}}]}`, `"from" and "to"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schedule.json")
+			writeQuotes(t, path, tt.content)
+
+			_, err := loadScheduleConfig(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduler_RunDue(t *testing.T) {
+	start := time.Date(2026, 10, 17, 10, 2, 0, 0, time.UTC)
+	s, out := newTestScheduler(t, `{"timezone": "UTC", "jobs": [{"name": "often", "schedule": "*/5 * * * *", "count": 2, "format": "json"}]}`, nil, start)
+
+	if wake := s.runDue(start.Add(time.Minute)); !wake.Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("wake = %s, want 10:05", wake)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("job ran early:\n%s", out)
+	}
+
+	due := start.Add(3 * time.Minute)
+	if wake := s.runDue(due); !wake.Equal(due.Add(5 * time.Minute)) {
+		t.Errorf("wake = %s, want 10:10", wake)
+	}
+	s.wait()
+
+	var quotes []Quote
+	if err := json.Unmarshal(out.Bytes(), &quotes); err != nil || len(quotes) != 2 {
+		t.Fatalf("expected 2 quotes as JSON, got %v:\n%s", err, out)
+	}
+
+	var state scheduleState
+	data, _ := os.ReadFile(s.statePath)
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("invalid state file: %v", err)
+	}
+	if !state.LastRun["often"].Equal(due) {
+		t.Errorf("last run = %s, want %s", state.LastRun["often"], due)
+	}
+}
+
+func TestScheduler_Missed(t *testing.T) {
+	lastRun := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	restart := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	state := &scheduleState{LastRun: map[string]time.Time{"daily": lastRun}}
+
+	tests := []struct {
+		policy  string
+		wantRun bool
+	}{
+		{missedCatchUp, true},
+		{missedSkip, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			config := `{"timezone": "UTC", "jobs": [{"name": "daily", "schedule": "0 9 * * *", "missed": "` + tt.policy + `"}]}`
+			s, out := newTestScheduler(t, config, state, restart)
+
+			// Two runs were missed, but catching up only delivers once
+			wake := s.runDue(restart)
+			s.wait()
+			if ran := out.Len() > 0; ran != tt.wantRun {
+				t.Errorf("ran = %t, want %t", ran, tt.wantRun)
+			}
+			if want := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC); !wake.Equal(want) {
+				t.Errorf("wake = %s, want %s", wake, want)
+			}
+		})
+	}
+}
+
+func TestScheduler_FileDestination(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		appendOpt string
+		wantLines int
+	}{
+		{"replace", "false", 1},
+		{"append", "true", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			config := `{"timezone": "UTC", "jobs": [{"name": "file", "schedule": "* * * * *", "format": "json",
+				"destination": {"type": "file", "path": "` + path + `", "append": ` + tt.appendOpt + `}}]}`
+			s, _ := newTestScheduler(t, config, nil, start)
+
+			s.runDue(start.Add(time.Minute))
+			s.wait()
+			s.runDue(start.Add(2 * time.Minute))
+			s.wait()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("destination file not written: %v", err)
+			}
+			if got := strings.Count(string(data), `"id"`); got != tt.wantLines {
+				t.Errorf("file holds %d quotes, want %d:\n%s", got, tt.wantLines, data)
+			}
+		})
+	}
+}
+
+func TestScheduler_WebhookDestination(t *testing.T) {
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	config := `{"timezone": "UTC", "jobs": [{"name": "chat", "schedule": "@hourly",
+		"destination": {"type": "webhook", "url": "` + srv.URL + `", "style": "mattermost"}}]}`
+	s, _ := newTestScheduler(t, config, nil, start)
+
+	s.runDue(start.Add(time.Hour))
+	s.wait()
+
+	var payload map[string]string
+	if err := json.Unmarshal(received, &payload); err != nil || !strings.HasPrefix(payload["text"], "> ") {
+		t.Errorf("expected a Mattermost payload, got %v: %s", err, received)
+	}
+}
+
+func TestScheduler_SlowJob(t *testing.T) {
+	var attempts atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		<-release
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "quote.txt")
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	config := `{"timezone": "UTC", "jobs": [
+		{"name": "slow", "schedule": "* * * * *", "destination": {"type": "webhook", "url": "` + srv.URL + `"}},
+		{"name": "file", "schedule": "* * * * *", "destination": {"type": "file", "path": "` + path + `"}}]}`
+	s, _ := newTestScheduler(t, config, nil, start)
+
+	s.runDue(start.Add(time.Minute))
+
+	// The file job finishes while the webhook is still waiting
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			close(release)
+			t.Fatal("file job was held up by the slow webhook job")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The slow job is still running, so its next run is skipped
+	s.runDue(start.Add(2 * time.Minute))
+	close(release)
+	s.wait()
+
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("webhook posted %d times, want 1", got)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := writeFileAtomic(path, []byte(strings.Repeat(strconv.Itoa(i%10), 1000))); err != nil {
+				t.Errorf("writeFileAtomic() unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1000 || strings.Count(string(data), string(data[:1])) != 1000 {
+		t.Errorf("file holds a mix of writes: %.40q", data)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only out.txt", len(entries))
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0644 {
+		t.Errorf("file mode = %v, want 0644", info.Mode().Perm())
+	}
+}
+
+func TestScheduleCommand_List(t *testing.T) {
+	isolateSources(t)
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "schedule.json")
+	writeQuotes(t, configPath, `{"timezone": "UTC", "jobs": [{"name": "morning", "schedule": "0 9 * * *"}]}`)
+
+	now = func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	output, err := executeCommand(newRootCommand(), "schedule", "--config", configPath, "--state", filepath.Join(dir, "state.json"), "--list")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "morning\t0 9 * * *\t2026-10-18T09:00:00Z\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
+
+func TestScheduleCommand_DefaultConfigIsNotQuotes(t *testing.T) {
+	home := isolateSources(t)
+	configPath, err := defaultSchedulePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeQuotes(t, configPath, `{"timezone": "UTC", "jobs": [{"name": "morning", "schedule": "0 9 * * *"}]}`)
+
+	var warnings bytes.Buffer
+	warnOutput = &warnings
+	defer func() { warnOutput = os.Stderr }()
+
+	// The collection loads without the schedule, even in strict mode
+	if _, err := loadCollection(nil, true); err != nil {
+		t.Errorf("loadCollection() with a schedule present: %v", err)
+	}
+	if _, err := executeCommand(newRootCommand(), "--strict", "schedule", "--state", filepath.Join(home, "state.json"), "--list"); err != nil {
+		t.Errorf("schedule --strict --list unexpected error: %v", err)
+	}
+	if warnings.Len() > 0 {
+		t.Errorf("loading warned about the schedule:\n%s", warnings.String())
+	}
+}
+
+func TestScheduler_SMTPDestination(t *testing.T) {
+	server, _ := newFakeSMTP(t, false)
+
+	start := time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC)
+	config := `{"timezone": "UTC", "jobs": [{"name": "digest", "schedule": "30 7 * * mon", "count": 3, "format": "json",
+		"destination": {"type": "smtp", "host": "127.0.0.1", "port": ` + strconv.Itoa(server.port) + `, "insecure": true,
+			"from": "quotes@example.com", "to": ["team@example.com"], "subject": "{{.Count}} quotes for {{.Date.Weekday}}"}}]}`
+	s, _ := newTestScheduler(t, config, nil, start)
+
+	now = func() time.Time { return start.Add(30 * time.Minute) }
+	defer func() { now = time.Now }()
+
+	s.runDue(start.Add(30 * time.Minute))
+	s.wait()
+	<-server.done
+
+	if !strings.Contains(server.data, "Subject: 3 quotes for Monday") || !strings.Contains(server.data, "multipart/alternative") {
+		t.Errorf("expected a 3-quote digest, got:\n%s", server.data)
+	}
+
+	// The plain-text part is in the job's format
+	msg, err := mail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	_, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	part, err := multipart.NewReader(msg.Body, params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatalf("message has no parts: %v", err)
+	}
+	var quotes []Quote
+	if err := json.NewDecoder(part).Decode(&quotes); err != nil || len(quotes) != 3 {
+		t.Errorf("plain-text part is not 3 quotes as JSON: %v", err)
+	}
+}
+
+func TestScheduler_SMTPRequiresStartTLS(t *testing.T) {
+	server, _ := newFakeSMTP(t, false)
+
+	start := time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC)
+	config := `{"timezone": "UTC", "jobs": [{"name": "digest", "schedule": "@daily",
+		"destination": {"type": "smtp", "host": "127.0.0.1", "port": ` + strconv.Itoa(server.port) + `,
+			"from": "quotes@example.com", "to": ["team@example.com"]}}]}`
+	s, _ := newTestScheduler(t, config, nil, start)
+
+	err := s.run(s.jobs[0], start)
+	if err == nil || !strings.Contains(err.Error(), `set "insecure": true`) {
+		t.Errorf("run() error = %v, want it to point at the insecure setting", err)
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// BM25 tuning parameters, using the customary defaults
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	// fuzzyWeight scales the contribution of terms matched by edit distance
+	// rather than exactly, so exact hits always rank first
+	fuzzyWeight = 0.5
+)
+
+// ErrEmptyQuery is returned when a search query contains no terms
+var ErrEmptyQuery = errors.New("empty search query")
+
+// ErrNoResults is returned when a search matches no quotes
+var ErrNoResults = errors.New("no quotes match the search")
+
+// SearchResult is a quote paired with its relevance score
+type SearchResult struct {
+	Quote
+	Score float64 `json:"score"`
+}
+
+// Query is a parsed search query. Terms are ranked, phrases must appear
+// verbatim, and excluded terms or phrases remove a quote from the results.
+type Query struct {
+	Terms    []string
+	Phrases  [][]string
+	Excluded [][]string
+}
+
+// ParseQuery splits a query into terms, "quoted phrases" and -negations.
+// A negation applies to the term or phrase it prefixes, as in -"pay off".
+func ParseQuery(query string) (Query, error) {
+	var q Query
+
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		negate := false
+		if rest[0] == '-' {
+			negate = true
+			rest = rest[1:]
+		}
+
+		var clause string
+		phrase := false
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				return Query{}, fmt.Errorf("unterminated phrase in query: %s", query)
+			}
+			clause, rest = rest[1:end+1], rest[end+2:]
+			phrase = true
+		} else if end := strings.IndexFunc(rest, unicode.IsSpace); end >= 0 {
+			clause, rest = rest[:end], rest[end:]
+		} else {
+			clause, rest = rest, ""
+		}
+		rest = strings.TrimSpace(rest)
+
+		tokens := tokenize(clause)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		switch {
+		case negate:
+			q.Excluded = append(q.Excluded, tokens)
+		case phrase:
+			q.Phrases = append(q.Phrases, tokens)
+			q.Terms = append(q.Terms, tokens...)
+		default:
+			q.Terms = append(q.Terms, tokens...)
+		}
+	}
+
+	if len(q.Terms) == 0 && len(q.Excluded) == 0 {
+		return Query{}, ErrEmptyQuery
+	}
+
+	return q, nil
+}
+
+// tokenize lowercases s and splits it into words. Apostrophes are dropped
+// rather than splitting, so "it's" and "its" index the same way.
+func tokenize(s string) []string {
+	s = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(s))
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// SearchIndex ranks quotes against queries with BM25 over their text and author
+type SearchIndex struct {
+	quotes    []Quote
+	docs      [][]string
+	termFreqs []map[string]int
+	docFreq   map[string]int
+	avgLen    float64
+}
+
+// NewSearchIndex tokenizes every quote's text and author into an index
+func NewSearchIndex(quotes []Quote) *SearchIndex {
+	ix := &SearchIndex{
+		quotes:    quotes,
+		docs:      make([][]string, len(quotes)),
+		termFreqs: make([]map[string]int, len(quotes)),
+		docFreq:   make(map[string]int),
+	}
+
+	total := 0
+	for i, q := range quotes {
+		tokens := append(tokenize(q.Text), tokenize(q.Author)...)
+		freqs := make(map[string]int)
+		for _, token := range tokens {
+			freqs[token]++
+		}
+		for token := range freqs {
+			ix.docFreq[token]++
+		}
+
+		ix.docs[i] = tokens
+		ix.termFreqs[i] = freqs
+		total += len(tokens)
+	}
+
+	if len(quotes) > 0 {
+		ix.avgLen = float64(total) / float64(len(quotes))
+	}
+
+	return ix
+}
+
+// Search returns the quotes matching query, best match first. With fuzzy set,
+// a term missing from the index also matches indexed words within a small
+// edit distance, so typos still find results.
+func (ix *SearchIndex) Search(query string, fuzzy bool) ([]SearchResult, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Expand each query term to the indexed words it matches, with a weight
+	expansions := make([]map[string]float64, len(q.Terms))
+	for i, term := range q.Terms {
+		expansions[i] = map[string]float64{}
+		if ix.docFreq[term] > 0 || !fuzzy {
+			expansions[i][term] = 1
+			continue
+		}
+		for word := range ix.docFreq {
+			if withinEditDistance(term, word) {
+				expansions[i][word] = fuzzyWeight
+			}
+		}
+	}
+
+	var results []SearchResult
+	for doc, tokens := range ix.docs {
+		if containsAny(tokens, q.Excluded) || !containsAll(tokens, q.Phrases) {
+			continue
+		}
+
+		score, matched := 0.0, false
+		for _, words := range expansions {
+			for word, weight := range words {
+				if tf := ix.termFreqs[doc][word]; tf > 0 {
+					score += weight * ix.bm25(word, tf, len(tokens))
+					matched = true
+				}
+			}
+		}
+
+		// A query made only of negations matches everything it doesn't exclude
+		if matched || len(q.Terms) == 0 {
+			results = append(results, SearchResult{Quote: ix.quotes[doc], Score: score})
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, ErrNoResults
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return results, nil
+}
+
+// bm25 scores a single term occurring tf times in a document of docLen tokens
+func (ix *SearchIndex) bm25(term string, tf, docLen int) float64 {
+	n := float64(len(ix.docs))
+	df := float64(ix.docFreq[term])
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+	freq := float64(tf)
+	norm := bm25K1 * (1 - bm25B + bm25B*float64(docLen)/ix.avgLen)
+	return idf * freq * (bm25K1 + 1) / (freq + norm)
+}
+
+// containsAll reports whether tokens contains every phrase as a contiguous run
+func containsAll(tokens []string, phrases [][]string) bool {
+	for _, phrase := range phrases {
+		if !containsPhrase(tokens, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsAny reports whether tokens contains at least one of the phrases
+func containsAny(tokens []string, phrases [][]string) bool {
+	for _, phrase := range phrases {
+		if containsPhrase(tokens, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPhrase(tokens, phrase []string) bool {
+	for start := 0; start+len(phrase) <= len(tokens); start++ {
+		match := true
+		for i, word := range phrase {
+			if tokens[start+i] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// withinEditDistance reports whether word is close enough to term to count as
+// a typo: one edit for terms of four to seven characters, two for longer ones.
+// Shorter terms must match exactly.
+func withinEditDistance(term, word string) bool {
+	length := utf8.RuneCountInString(term)
+
+	var maxDistance int
+	switch {
+	case length < 4:
+		return false
+	case length < 8:
+		maxDistance = 1
+	default:
+		maxDistance = 2
+	}
+
+	if diff := length - utf8.RuneCountInString(word); diff > maxDistance || -diff > maxDistance {
+		return false
+	}
+
+	return levenshtein([]rune(term), []rune(word)) <= maxDistance
+}
+
+// levenshtein computes the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+var (
+	searchLimit     int
+	searchShowScore bool
+	searchFuzzy     bool
+)
+
+// newSearchCommand creates the search subcommand
+func newSearchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "search <terms>",
+		Short: "Search quotes by relevance",
+		Long: `Search the active collection by text and author, ranked by BM25 relevance.
+
+Wrap words in double quotes to require an exact phrase, and prefix a word or
+phrase with - to exclude quotes containing it:
+
+  quotes search code
+  quotes search '"the code" -cheap'`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runSearch,
+	}
+
+	addOutputFlags(cmd)
+	cmd.Flags().IntVar(&searchLimit, "limit", 10, "Maximum number of results (0 for all)")
+	cmd.Flags().BoolVar(&searchShowScore, "score", false, "Include the relevance score in the output")
+	cmd.Flags().BoolVar(&searchFuzzy, "fuzzy", true, "Match words within a small edit distance of unknown terms")
+
+	return cmd
+}
+
+// runSearch ranks the filtered collection against the query arguments
+func runSearch(cmd *cobra.Command, args []string) error {
+	out, err := newOutput()
+	if err != nil {
+		return err
+	}
+	if out.isTemplate() && searchShowScore {
+		return fmt.Errorf("--score cannot be used with a template")
+	}
+	if format == "say" && searchShowScore {
+		return fmt.Errorf("--score cannot be used with --format say")
+	}
+
+	if searchLimit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+
+	quotes, err := loadFilteredQuotes()
+	if err != nil {
+		return err
+	}
+
+	results, err := NewSearchIndex(quotes).Search(strings.Join(args, " "), searchFuzzy)
+	if err != nil {
+		return err
+	}
+
+	if searchLimit > 0 && len(results) > searchLimit {
+		results = results[:searchLimit]
+	}
+
+	if !searchShowScore {
+		quotes := make([]Quote, len(results))
+		for i, r := range results {
+			quotes[i] = r.Quote
+		}
+		result, err := out.render(quotes)
+		if err != nil {
+			return err
+		}
+		fmt.Print(result)
+		return nil
+	}
+
+	if format == "text" && !out.text.isPlain() {
+		fmt.Print(renderSearchText(results, out.text))
+		return nil
+	}
+
+	fmt.Print(asDocument(format, FormatSearchResults(results, format, true)))
+	return nil
+}
+
+// renderSearchText formats search results with their scores like
+// FormatSearchResults does for text, wrapped, coloured and framed as the
+// style asks
+func renderSearchText(results []SearchResult, style textStyle) string {
+	blocks := make([][]textLine, len(results))
+	for i, r := range results {
+		prefix := ""
+		if len(results) > 1 {
+			prefix = fmt.Sprintf("%d. ", i+1)
+		}
+		blocks[i] = style.quoteLines(r.Quote, prefix, []string{fmt.Sprintf("Score: %.3f", r.Score)})
+	}
+	return style.render(blocks)
+}
+
+// FormatSearchResults renders search results in the named output format.
+// Without withScore the output matches the regular formatters exactly; with it,
+// each quote is followed by its score (or carries a "score" key in JSON).
+func FormatSearchResults(results []SearchResult, format string, withScore bool) string {
+	if !withScore {
+		quotes := make([]Quote, len(results))
+		for i, r := range results {
+			quotes[i] = r.Quote
+		}
+		return formatQuotes(format, quotes)
+	}
+
+	if format == "json" {
+		if results == nil {
+			results = []SearchResult{}
+		}
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return "[]"
+		}
+		return string(b)
+	}
+
+	var result strings.Builder
+	for i, r := range results {
+		switch format {
+		case "markdown":
+			fmt.Fprintf(&result, "%sScore: %.3f\n\n", FormatMarkdown([]Quote{r.Quote}), r.Score)
+		case "html":
+			fmt.Fprintf(&result, "%s<p class=\"quote-score\">Score: %.3f</p>\n", FormatHTML([]Quote{r.Quote}), r.Score)
+		default:
+			if len(results) > 1 {
+				fmt.Fprintf(&result, "%d. ", i+1)
+			}
+			fmt.Fprintf(&result, "%s     Score: %.3f\n", FormatText([]Quote{r.Quote}), r.Score)
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"Talk is cheap. Show me the code", []string{"talk", "is", "cheap", "show", "me", "the", "code"}},
+		{"It's not a bug – it's", []string{"its", "not", "a", "bug", "its"}},
+		{"error-free programs", []string{"error", "free", "programs"}},
+		{"C.A.R. Hoare", []string{"c", "a", "r", "hoare"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := tokenize(tt.input)
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    Query
+		wantErr bool
+	}{
+		{
+			name:  "terms",
+			query: "simple Code",
+			want:  Query{Terms: []string{"simple", "code"}},
+		},
+		{
+			name:  "phrase",
+			query: `"hard things" naming`,
+			want:  Query{Terms: []string{"hard", "things", "naming"}, Phrases: [][]string{{"hard", "things"}}},
+		},
+		{
+			name:  "negation",
+			query: `code -poetry -"stay hungry"`,
+			want:  Query{Terms: []string{"code"}, Excluded: [][]string{{"poetry"}, {"stay", "hungry"}}},
+		},
+		{
+			name:    "unterminated phrase",
+			query:   `"hard things`,
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			query:   "  -- ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuery(tt.query)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseQuery() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"code", "code", 0},
+		{"code", "cod", 1},
+		{"kitten", "sitting", 3},
+		{"naïve", "naive", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSearchIndex_Search(t *testing.T) {
+	ix := NewSearchIndex(withIDs(defaultQuotes))
+
+	tests := []struct {
+		name       string
+		query      string
+		fuzzy      bool
+		wantFirst  string
+		wantAuthor string
+		wantNone   string
+		wantErr    error
+	}{
+		{
+			name:      "single term",
+			query:     "poetry",
+			wantFirst: "Code is poetry",
+		},
+		{
+			name:      "author is indexed",
+			query:     "torvalds",
+			wantFirst: "Talk is cheap. Show me the code",
+		},
+		{
+			name:      "rarer term ranks higher",
+			query:     "code invalidation",
+			wantFirst: "There are only two hard things in Computer Science: cache invalidation and naming things",
+		},
+		{
+			name:      "phrase",
+			query:     `"hard things"`,
+			wantFirst: "There are only two hard things in Computer Science: cache invalidation and naming things",
+		},
+		{
+			name:     "negation",
+			query:    "code -poetry",
+			wantNone: "Code is poetry",
+		},
+		{
+			name:       "fuzzy typo",
+			query:      "dijkstar",
+			fuzzy:      true,
+			wantAuthor: "Edsger Dijkstra",
+		},
+		{
+			name:    "typo without fuzzy",
+			query:   "dijkstar",
+			wantErr: ErrNoResults,
+		},
+		{
+			name:    "no results",
+			query:   "xylophone",
+			fuzzy:   true,
+			wantErr: ErrNoResults,
+		},
+		{
+			name:    "empty query",
+			query:   "   ",
+			wantErr: ErrEmptyQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := ix.Search(tt.query, tt.fuzzy)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Search() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Search() unexpected error: %v", err)
+			}
+
+			if tt.wantFirst != "" && results[0].Text != tt.wantFirst {
+				t.Errorf("Search() first result = %q, want %q", results[0].Text, tt.wantFirst)
+			}
+
+			if tt.wantAuthor != "" && results[0].Author != tt.wantAuthor {
+				t.Errorf("Search() first result by %q, want %q", results[0].Author, tt.wantAuthor)
+			}
+
+			for i, r := range results {
+				if r.Text == tt.wantNone {
+					t.Errorf("Search() returned excluded quote %q", r.Text)
+				}
+				if i > 0 && r.Score > results[i-1].Score {
+					t.Errorf("Search() results not sorted by score at %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatSearchResults(t *testing.T) {
+	results := []SearchResult{
+		{Quote: Quote{Text: "Be", Author: "Gandhi"}, Score: 1.5},
+		{Quote: Quote{Text: "Code", Author: "Unknown"}, Score: 0.25},
+	}
+
+	tests := []struct {
+		name      string
+		format    string
+		withScore bool
+		want      string
+	}{
+		{
+			name:   "text without score matches FormatText",
+			format: "text",
+			want:   FormatText([]Quote{results[0].Quote, results[1].Quote}),
+		},
+		{
+			name:      "text with score",
+			format:    "text",
+			withScore: true,
+			want:      "1. Be\n   - Gandhi\n     Score: 1.500\n2. Code\n   - Unknown\n     Score: 0.250\n",
+		},
+		{
+			name:      "markdown with score",
+			format:    "markdown",
+			withScore: true,
+			want:      "> Be\n\n— Gandhi\n\nScore: 1.500\n\n> Code\n\n— Unknown\n\nScore: 0.250\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatSearchResults(results, tt.format, tt.withScore)
+			if got != tt.want {
+				t.Errorf("FormatSearchResults() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("json with score", func(t *testing.T) {
+		got := FormatSearchResults(results, "json", true)
+
+		var parsed []map[string]interface{}
+		if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+			t.Fatalf("FormatSearchResults() produced invalid JSON: %v", err)
+		}
+		if parsed[0]["text"] != "Be" || parsed[0]["score"] != 1.5 {
+			t.Errorf("FormatSearchResults() = %s, want flattened quote with score", got)
+		}
+	})
+}
+
+func TestSearchCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantErr   string
+		wantMatch string
+	}{
+		{
+			name:      "basic search",
+			args:      []string{"search", "debugging"},
+			wantMatch: "Kernighan",
+		},
+		{
+			name:      "limit",
+			args:      []string{"search", "code", "--limit", "2", "--format", "json"},
+			wantMatch: `"text"`,
+		},
+		{
+			name:      "score",
+			args:      []string{"search", "poetry", "--score"},
+			wantMatch: "Score:",
+		},
+		{
+			name:      "filters apply before search",
+			args:      []string{"search", "code", "--author", "Torvalds"},
+			wantMatch: "Talk is cheap",
+		},
+		{
+			name:    "no results",
+			args:    []string{"search", "xylophone"},
+			wantErr: "no quotes match the search",
+		},
+		{
+			name:    "missing terms",
+			args:    []string{"search"},
+			wantErr: "requires at least 1 arg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newRootCommand()
+			output, err := executeCommand(cmd, tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !strings.Contains(output, tt.wantMatch) {
+				t.Errorf("output %q does not contain %q", output, tt.wantMatch)
+			}
+		})
+	}
+}
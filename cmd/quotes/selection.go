@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// ErrNotEnoughQuotes is returned when more distinct quotes are requested than
+// the collection holds and repeats are not allowed
+var ErrNotEnoughQuotes = errors.New("not enough quotes")
+
+// SelectQuotes returns n quotes drawn from a seeded permutation of the provided
+// slice, so every quote in the result is distinct. When n exceeds the number of
+// available quotes, ErrNotEnoughQuotes is returned unless allowRepeats is set, in
+// which case the collection is reshuffled and dealt again once exhausted.
+// Returns ErrNoQuotes if the quotes slice is empty.
+func SelectQuotes(quotes []Quote, n int, seed int64, allowRepeats bool) ([]Quote, error) {
+	if len(quotes) == 0 {
+		return nil, ErrNoQuotes
+	}
+
+	if n > len(quotes) && !allowRepeats {
+		return nil, fmt.Errorf("%w: requested %d but only %d available (use --allow-repeats)", ErrNotEnoughQuotes, n, len(quotes))
+	}
+
+	// A single source drives every pass so the whole selection is reproducible
+	rng := rand.New(rand.NewSource(seed))
+
+	selected := make([]Quote, 0, n)
+	for len(selected) < n {
+		for _, index := range rng.Perm(len(quotes)) {
+			if len(selected) == n {
+				break
+			}
+			selected = append(selected, quotes[index])
+		}
+	}
+
+	return selected, nil
+}
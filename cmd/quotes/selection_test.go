@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSelectQuotes_Distinct(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		got, err := SelectQuotes(sampleQuotes, len(sampleQuotes), seed, false)
+		if err != nil {
+			t.Fatalf("SelectQuotes failed: %v", err)
+		}
+
+		if len(got) != len(sampleQuotes) {
+			t.Fatalf("SelectQuotes returned %d quotes, want %d", len(got), len(sampleQuotes))
+		}
+
+		seen := make(map[string]bool)
+		for _, q := range got {
+			if seen[q.Text] {
+				t.Errorf("seed %d: duplicate quote %q", seed, q.Text)
+			}
+			seen[q.Text] = true
+		}
+	}
+}
+
+func TestSelectQuotes_DeterministicSeed(t *testing.T) {
+	first, err := SelectQuotes(sampleQuotes, 3, 42, false)
+	if err != nil {
+		t.Fatalf("SelectQuotes failed: %v", err)
+	}
+
+	second, err := SelectQuotes(sampleQuotes, 3, 42, false)
+	if err != nil {
+		t.Fatalf("SelectQuotes failed: %v", err)
+	}
+
+	for i := range first {
+		if first[i].Text != second[i].Text {
+			t.Errorf("same seed produced different selection at %d: %q vs %q", i, first[i].Text, second[i].Text)
+		}
+	}
+}
+
+func TestSelectQuotes_EdgeCases(t *testing.T) {
+	tests := []struct {
+		name         string
+		quotes       []Quote
+		n            int
+		allowRepeats bool
+		wantLen      int
+		wantErr      error
+	}{
+		{
+			name:    "empty list",
+			quotes:  []Quote{},
+			n:       1,
+			wantErr: ErrNoQuotes,
+		},
+		{
+			name:    "count exceeds collection",
+			quotes:  sampleQuotes,
+			n:       len(sampleQuotes) + 1,
+			wantErr: ErrNotEnoughQuotes,
+		},
+		{
+			name:         "count exceeds collection with repeats",
+			quotes:       sampleQuotes,
+			n:            len(sampleQuotes)*2 + 1,
+			allowRepeats: true,
+			wantLen:      len(sampleQuotes)*2 + 1,
+		},
+		{
+			name:    "zero count",
+			quotes:  sampleQuotes,
+			n:       0,
+			wantLen: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectQuotes(tt.quotes, tt.n, 42, tt.allowRepeats)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("SelectQuotes() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("SelectQuotes() unexpected error: %v", err)
+			}
+
+			if len(got) != tt.wantLen {
+				t.Errorf("SelectQuotes() returned %d quotes, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestSelectQuotes_RepeatsExhaustCollectionFirst(t *testing.T) {
+	got, err := SelectQuotes(sampleQuotes, len(sampleQuotes)*2, 7, true)
+	if err != nil {
+		t.Fatalf("SelectQuotes failed: %v", err)
+	}
+
+	// Each full pass over the collection should be a complete permutation
+	for pass := 0; pass < 2; pass++ {
+		seen := make(map[string]bool)
+		for _, q := range got[pass*len(sampleQuotes) : (pass+1)*len(sampleQuotes)] {
+			seen[q.Text] = true
+		}
+		if len(seen) != len(sampleQuotes) {
+			t.Errorf("pass %d contained %d distinct quotes, want %d", pass, len(seen), len(sampleQuotes))
+		}
+	}
+}
@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// shutdownTimeout bounds how long a graceful shutdown waits for in-flight
+// requests before closing their connections
+const shutdownTimeout = 10 * time.Second
+
+// Timeouts for idle clients, so slow or stalled connections can't hold the
+// server's resources open indefinitely
+const (
+	readHeaderTimeout = 10 * time.Second
+	idleTimeout       = 2 * time.Minute
+)
+
+// mediaTypes maps each output format to the content type it is served as
+var mediaTypes = map[string]string{
+	"json":     "application/json; charset=utf-8",
+	"text":     "text/plain; charset=utf-8",
+	"markdown": "text/markdown; charset=utf-8",
+}
+
+// acceptTypes maps Accept header media ranges to output formats
+var acceptTypes = map[string]string{
+	"application/json": "json",
+	"text/plain":       "text",
+	"text/markdown":    "markdown",
+	"text/x-markdown":  "markdown",
+	"text/*":           "text",
+	"application/*":    "json",
+	"*/*":              "json",
+}
+
+// serveListen holds the --listen flag value
+var serveListen string
+
+// newServeCommand creates the serve subcommand, which exposes the collection
+// over HTTP
+func newServeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve quotes over HTTP",
+		Long: `Serve the active collection over HTTP.
+
+Endpoints:
+  GET /quotes           list the collection (sort, reverse, limit, offset, fields)
+  GET /quotes/random    random quotes (count, seed, allow_repeats)
+  GET /quotes/today     quote of the period (period, tz, date)
+  GET /quotes/search    ranked search (q, limit, fuzzy, score)
+  GET /quotes/{id}      a single quote by ID
+
+Every endpoint also accepts the filters author, exact_author, min_length,
+max_length, match and tag. Responses are JSON, text or markdown, chosen by the
+Accept header or a format query parameter.
+
+SIGTERM or SIGINT shuts the server down gracefully, and SIGHUP reloads the
+collection without dropping requests.`,
+		Args: cobra.NoArgs,
+		RunE: runServe,
+	}
+
+	cmd.Flags().StringVar(&serveListen, "listen", "localhost:8080", "Address to listen on")
+
+	return cmd
+}
+
+// runServe serves the collection until SIGTERM or SIGINT
+func runServe(cmd *cobra.Command, args []string) error {
+	store, err := newQuoteStore(loadFilteredQuotes)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", serveListen)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("serving %d quotes on http://%s", len(store.Collection().quotes), listener.Addr())
+	return serveUntilSignalled(newHTTPServer(newServeHandler(store)), listener, store)
+}
+
+// newHTTPServer returns a server for handler that drops clients which are
+// slow to send request headers or sit idle between requests. It sets no
+// write timeout, so long-lived responses such as event streams still work.
+func newHTTPServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
+
+// serveUntilSignalled runs srv on listener until SIGTERM or SIGINT, then
+// shuts it down gracefully. SIGHUP reloads store.
+func serveUntilSignalled(srv *http.Server, listener net.Listener, store *quoteStore) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	return serveUntilDone(ctx, srv, listener, reload, store)
+}
+
+// serveUntilDone runs srv on listener until ctx is cancelled, reloading store
+// each time a value arrives on reload. Shutdown waits up to shutdownTimeout
+// for in-flight requests.
+func serveUntilDone(ctx context.Context, srv *http.Server, listener net.Listener, reload <-chan os.Signal, store *quoteStore) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(listener)
+	}()
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case <-reload:
+			reloadStore(store)
+		case <-ctx.Done():
+			log.Printf("shutting down")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+			return srv.Shutdown(shutdownCtx)
+		}
+	}
+}
+
+// reloadStore reloads store, logging the outcome. A failed reload keeps the
+// previous collection.
+func reloadStore(store *quoteStore) {
+	if err := store.Reload(); err != nil {
+		log.Printf("reload failed, keeping %d quotes: %v", len(store.Collection().quotes), err)
+		return
+	}
+	log.Printf("reloaded %d quotes", len(store.Collection().quotes))
+}
+
+// newServeHandler routes the HTTP API to handlers reading from store
+func newServeHandler(store *quoteStore) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /quotes", func(w http.ResponseWriter, r *http.Request) {
+		handleList(w, r, store.Collection())
+	})
+	mux.HandleFunc("GET /quotes/random", func(w http.ResponseWriter, r *http.Request) {
+		handleRandom(w, r, store.Collection())
+	})
+	mux.HandleFunc("GET /quotes/today", func(w http.ResponseWriter, r *http.Request) {
+		handleToday(w, r, store.Collection())
+	})
+	mux.HandleFunc("GET /quotes/search", func(w http.ResponseWriter, r *http.Request) {
+		handleSearch(w, r, store.Collection())
+	})
+	mux.HandleFunc("GET /quotes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handleQuote(w, r, store.Collection())
+	})
+	return mux
+}
+
+// httpError is an error with the HTTP status it should be reported as
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+// badRequest builds a 400 error
+func badRequest(format string, args ...interface{}) error {
+	return &httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// handleList serves the filtered, sorted and paged collection
+func handleList(w http.ResponseWriter, r *http.Request, c *collection) {
+	respond(w, r, func(format string) (string, error) {
+		params := r.URL.Query()
+
+		quotes, err := filteredQuotes(c, params)
+		if err != nil {
+			return "", err
+		}
+
+		reverse, err := boolParam(params, "reverse", false)
+		if err != nil {
+			return "", err
+		}
+		if err := SortQuotes(quotes, params.Get("sort"), reverse); err != nil {
+			return "", badRequest("%s", err)
+		}
+
+		limit, err := intParam(params, "limit", 0)
+		if err != nil {
+			return "", err
+		}
+		offset, err := intParam(params, "offset", 0)
+		if err != nil {
+			return "", err
+		}
+		quotes = pageQuotes(quotes, offset, limit)
+
+		if fields := params.Get("fields"); fields != "" {
+			names := strings.Split(fields, ",")
+			for _, field := range names {
+				if !isValidField(field) {
+					return "", badRequest("invalid field: %s (must be one of: %s)", field, strings.Join(quoteFields, ", "))
+				}
+			}
+			return FormatFields(quotes, names, format), nil
+		}
+
+		return formatQuotes(format, quotes), nil
+	})
+}
+
+// handleRandom serves distinct random quotes, like the root command
+func handleRandom(w http.ResponseWriter, r *http.Request, c *collection) {
+	respond(w, r, func(format string) (string, error) {
+		params := r.URL.Query()
+
+		quotes, err := filteredQuotes(c, params)
+		if err != nil {
+			return "", err
+		}
+
+		n, err := intParam(params, "count", 1)
+		if err != nil {
+			return "", err
+		}
+		if n < 1 || n > 100 {
+			return "", badRequest("count must be 1-100, got %d", n)
+		}
+
+		seed, err := int64Param(params, "seed", time.Now().UnixNano())
+		if err != nil {
+			return "", err
+		}
+
+		repeats, err := boolParam(params, "allow_repeats", false)
+		if err != nil {
+			return "", err
+		}
+
+		selected, err := SelectQuotes(quotes, n, seed, repeats)
+		if err != nil {
+			return "", badRequest("%s", err)
+		}
+
+		return formatQuotes(format, selected), nil
+	})
+}
+
+// handleToday serves the quote of the day, week or month
+func handleToday(w http.ResponseWriter, r *http.Request, c *collection) {
+	respond(w, r, func(format string) (string, error) {
+		params := r.URL.Query()
+
+		quotes, err := filteredQuotes(c, params)
+		if err != nil {
+			return "", err
+		}
+
+		tz := params.Get("tz")
+		if tz == "" {
+			tz = "UTC"
+		}
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return "", badRequest("invalid timezone: %s", tz)
+		}
+
+		t := now().In(loc)
+		if date := params.Get("date"); date != "" {
+			if t, err = time.ParseInLocation("2006-01-02", date, loc); err != nil {
+				return "", badRequest("invalid date: %s (must be YYYY-MM-DD)", date)
+			}
+		}
+
+		period := params.Get("period")
+		if period == "" {
+			period = "day"
+		}
+
+		q, err := QuoteOfThePeriod(quotes, t, period)
+		if err != nil {
+			return "", badRequest("%s", err)
+		}
+
+		return formatQuotes(format, []Quote{q}), nil
+	})
+}
+
+// handleSearch serves ranked search results
+func handleSearch(w http.ResponseWriter, r *http.Request, c *collection) {
+	respond(w, r, func(format string) (string, error) {
+		params := r.URL.Query()
+
+		quotes, err := filteredQuotes(c, params)
+		if err != nil {
+			return "", err
+		}
+
+		limit, err := intParam(params, "limit", 10)
+		if err != nil {
+			return "", err
+		}
+		fuzzy, err := boolParam(params, "fuzzy", true)
+		if err != nil {
+			return "", err
+		}
+		withScore, err := boolParam(params, "score", false)
+		if err != nil {
+			return "", err
+		}
+
+		// Reuse the collection's index unless the filters narrowed it, so
+		// scores match what the search subcommand reports
+		index := c.index
+		if len(quotes) != len(c.quotes) {
+			index = NewSearchIndex(quotes)
+		}
+
+		results, err := index.Search(params.Get("q"), fuzzy)
+		if errors.Is(err, ErrNoResults) {
+			return "", &httpError{status: http.StatusNotFound, message: err.Error()}
+		}
+		if err != nil {
+			return "", badRequest("%s", err)
+		}
+
+		if limit > 0 && len(results) > limit {
+			results = results[:limit]
+		}
+
+		return FormatSearchResults(results, format, withScore), nil
+	})
+}
+
+// handleQuote serves a single quote by ID
+func handleQuote(w http.ResponseWriter, r *http.Request, c *collection) {
+	respond(w, r, func(format string) (string, error) {
+		id := r.PathValue("id")
+		q, ok := c.byID[id]
+		if !ok {
+			return "", &httpError{status: http.StatusNotFound, message: fmt.Sprintf("no quote with id %s", id)}
+		}
+
+		return formatQuotes(format, []Quote{q}), nil
+	})
+}
+
+// respond negotiates the output format, runs render and writes its output,
+// or the error it returns with a matching status code
+func respond(w http.ResponseWriter, r *http.Request, render func(format string) (string, error)) {
+	format, ok := negotiateFormat(r)
+	if !ok {
+		writeError(w, "text", &httpError{status: http.StatusNotAcceptable, message: "acceptable formats: application/json, text/plain, text/markdown"})
+		return
+	}
+
+	body, err := render(format)
+	if err != nil {
+		writeError(w, format, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypes[format])
+	w.Header().Set("Vary", "Accept")
+	fmt.Fprint(w, body)
+}
+
+// writeError reports err in the negotiated format. Errors that aren't
+// httpErrors are reported as internal server errors.
+func writeError(w http.ResponseWriter, format string, err error) {
+	status := http.StatusInternalServerError
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		status = httpErr.status
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", mediaTypes["json"])
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypes["text"])
+	w.WriteHeader(status)
+	fmt.Fprintln(w, err.Error())
+}
+
+// negotiateFormat picks the output format from the format query parameter,
+// or else from the Accept header, preferring higher quality values and then
+// earlier entries. Reports false when nothing acceptable is offered.
+func negotiateFormat(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format, isValidFormat(format) && mediaTypes[format] != ""
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return "json", true
+	}
+
+	type candidate struct {
+		format  string
+		quality float64
+		order   int
+	}
+
+	var candidates []candidate
+	for i, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if format, ok := acceptTypes[mediaType]; ok && quality > 0 {
+			candidates = append(candidates, candidate{format: format, quality: quality, order: i})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].format, true
+}
+
+// filteredQuotes applies the filter query parameters to the collection,
+// returning a copy that handlers may reorder
+func filteredQuotes(c *collection, params url.Values) ([]Quote, error) {
+	filter, err := filterFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes, err := FilterQuotes(c.quotes, filter)
+	if err != nil {
+		return nil, &httpError{status: http.StatusNotFound, message: err.Error()}
+	}
+	return quotes, nil
+}
+
+// filterFromParams builds a Filter from the author, exact_author,
+// min_length, max_length, match and tag query parameters
+func filterFromParams(params url.Values) (Filter, error) {
+	exact, err := boolParam(params, "exact_author", false)
+	if err != nil {
+		return Filter{}, err
+	}
+	minLength, err := intParam(params, "min_length", 0)
+	if err != nil {
+		return Filter{}, err
+	}
+	maxLength, err := intParam(params, "max_length", 0)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	f := Filter{
+		Author:      params.Get("author"),
+		ExactAuthor: exact,
+		MinLength:   minLength,
+		MaxLength:   maxLength,
+		Tag:         params.Get("tag"),
+	}
+
+	if f.MaxLength > 0 && f.MinLength > f.MaxLength {
+		return Filter{}, badRequest("min_length %d exceeds max_length %d", f.MinLength, f.MaxLength)
+	}
+
+	if pattern := params.Get("match"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return Filter{}, badRequest("invalid match pattern: %s", err)
+		}
+		f.Match = re
+	}
+
+	return f, nil
+}
+
+// intParam parses a non-negative integer query parameter
+func intParam(params url.Values, name string, fallback int) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, badRequest("%s must be a non-negative integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+// int64Param parses a 64-bit integer query parameter
+func int64Param(params url.Values, name string, fallback int64) (int64, error) {
+	value := params.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, badRequest("%s must be an integer, got %q", name, value)
+	}
+	return n, nil
+}
+
+// boolParam parses a boolean query parameter
+func boolParam(params url.Values, name string, fallback bool) (bool, error) {
+	value := params.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, badRequest("%s must be true or false, got %q", name, value)
+	}
+	return b, nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestHandler serves the built-in quotes
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return withIDs(defaultQuotes), nil
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+	return newServeHandler(store)
+}
+
+func TestServeHandler(t *testing.T) {
+	handler := newTestHandler(t)
+	first := withIDs(defaultQuotes)[0]
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		wantStatus  int
+		wantType    string
+		wantContain string
+	}{
+		{name: "list", target: "/quotes", wantStatus: 200, wantType: "application/json", wantContain: first.Text},
+		{name: "list paged", target: "/quotes?sort=length&limit=2", wantStatus: 200, wantType: "application/json"},
+		{name: "list fields", target: "/quotes?fields=id,author&format=text", wantStatus: 200, wantType: "text/plain", wantContain: first.ID + "\t" + first.Author},
+		{name: "list invalid sort", target: "/quotes?sort=colour", wantStatus: 400, wantType: "application/json", wantContain: `"error"`},
+		{name: "list filtered", target: "/quotes?author=jobs", wantStatus: 200, wantType: "application/json", wantContain: "Steve Jobs"},
+		{name: "no matches", target: "/quotes?author=nobody", wantStatus: 404, wantType: "application/json", wantContain: "no quotes match"},
+		{name: "invalid match", target: "/quotes?match=(", wantStatus: 400, wantType: "application/json", wantContain: "invalid match pattern"},
+		{name: "by id", target: "/quotes/" + first.ID, wantStatus: 200, wantType: "application/json", wantContain: first.Text},
+		{name: "unknown id", target: "/quotes/ffffffff", wantStatus: 404, wantType: "application/json", wantContain: "no quote with id ffffffff"},
+		{name: "random", target: "/quotes/random?count=3&seed=1", wantStatus: 200, wantType: "application/json"},
+		{name: "random invalid count", target: "/quotes/random?count=0", wantStatus: 400, wantType: "application/json"},
+		{name: "random bad seed", target: "/quotes/random?seed=abc", wantStatus: 400, wantType: "application/json"},
+		{name: "today", target: "/quotes/today?date=2026-10-17&tz=Europe/Dublin", wantStatus: 200, wantType: "application/json"},
+		{name: "today invalid period", target: "/quotes/today?period=year", wantStatus: 400, wantType: "application/json", wantContain: "invalid period"},
+		{name: "today invalid tz", target: "/quotes/today?tz=Mars/Olympus", wantStatus: 400, wantType: "application/json", wantContain: "invalid timezone"},
+		{name: "search", target: "/quotes/search?q=code&score=true", wantStatus: 200, wantType: "application/json", wantContain: `"score"`},
+		{name: "search no results", target: "/quotes/search?q=xylophone&fuzzy=false", wantStatus: 404, wantType: "application/json"},
+		{name: "search empty query", target: "/quotes/search", wantStatus: 400, wantType: "application/json"},
+		{name: "accept text", target: "/quotes/" + first.ID, accept: "text/plain", wantStatus: 200, wantType: "text/plain", wantContain: "- " + first.Author},
+		{name: "accept markdown", target: "/quotes/" + first.ID, accept: "text/markdown", wantStatus: 200, wantType: "text/markdown", wantContain: "> " + first.Text},
+		{name: "accept quality", target: "/quotes/" + first.ID, accept: "application/json;q=0.5, text/markdown", wantStatus: 200, wantType: "text/markdown"},
+		{name: "accept wildcard", target: "/quotes/" + first.ID, accept: "*/*", wantStatus: 200, wantType: "application/json"},
+		{name: "accept text wildcard", target: "/quotes/" + first.ID, accept: "text/*", wantStatus: 200, wantType: "text/plain"},
+		{name: "format overrides accept", target: "/quotes/" + first.ID + "?format=markdown", accept: "application/json", wantStatus: 200, wantType: "text/markdown"},
+		{name: "not acceptable", target: "/quotes", accept: "image/png", wantStatus: 406, wantType: "text/plain"},
+		{name: "invalid format", target: "/quotes?format=yaml", wantStatus: 406, wantType: "text/plain"},
+		{name: "text error", target: "/quotes/ffffffff", accept: "text/plain", wantStatus: 404, wantType: "text/plain", wantContain: "no quote with id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantContain) {
+				t.Errorf("body does not contain %q:\n%s", tt.wantContain, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServeHandler_JSON(t *testing.T) {
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		name   string
+		target string
+		want   int
+	}{
+		{name: "list page", target: "/quotes?offset=68&limit=5", want: 2},
+		{name: "random count", target: "/quotes/random?count=4&seed=7", want: 4},
+		{name: "search limit", target: "/quotes/search?q=the&limit=3", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			var quotes []Quote
+			if err := json.Unmarshal(rec.Body.Bytes(), &quotes); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, rec.Body.String())
+			}
+			if len(quotes) != tt.want {
+				t.Errorf("got %d quotes, want %d", len(quotes), tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHandler_ReservedIDs(t *testing.T) {
+	handler := newTestHandler(t)
+
+	// Each reserved ID is taken by another endpoint, which is why quotes can't
+	// use it; an ID missing from the list would reach the single-quote lookup
+	for _, id := range reservedIDs {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/quotes/"+id+"?q=code", nil))
+
+		if strings.Contains(rec.Body.String(), "no quote with id") {
+			t.Errorf("GET /quotes/%s reached the lookup by ID, so %q needn't be reserved", id, id)
+		}
+	}
+
+	// And a quote file can't claim one
+	_, problems := ParseQuotes([]byte(`[{"id": "today", "text": "a", "author": "b"}]`))
+	if len(problems) != 1 {
+		t.Errorf("ParseQuotes() problems = %v, want the reserved id", problems)
+	}
+}
+
+func TestServeHandler_MethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHandler(t).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/quotes", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestNewHTTPServer(t *testing.T) {
+	srv := newHTTPServer(http.NotFoundHandler())
+
+	if srv.ReadHeaderTimeout != readHeaderTimeout || srv.IdleTimeout != idleTimeout {
+		t.Errorf("timeouts = %v header, %v idle, want %v and %v", srv.ReadHeaderTimeout, srv.IdleTimeout, readHeaderTimeout, idleTimeout)
+	}
+	if srv.WriteTimeout != 0 {
+		t.Errorf("WriteTimeout = %v, want none so streams stay open", srv.WriteTimeout)
+	}
+}
+
+func TestServeUntilDone(t *testing.T) {
+	quotes := withIDs(defaultQuotes)
+	loaded := quotes[:2]
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return loaded, nil
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reload := make(chan os.Signal)
+	done := make(chan error, 1)
+	go func() {
+		done <- serveUntilDone(ctx, &http.Server{Handler: newServeHandler(store)}, listener, reload, store)
+	}()
+
+	count := func() int {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/quotes")
+		if err != nil {
+			t.Fatalf("GET /quotes: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var got []Quote
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		return len(got)
+	}
+
+	if got := count(); got != 2 {
+		t.Errorf("served %d quotes, want 2", got)
+	}
+
+	loaded = quotes[:4]
+	reload <- os.Interrupt // any signal triggers a reload
+	// The unbuffered send only completes once the loop has taken the signal;
+	// a second send waits for the reload to finish
+	reload <- os.Interrupt
+	if got := count(); got != 4 {
+		t.Errorf("served %d quotes after reload, want 4", got)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveUntilDone() error = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveUntilDone() did not return after cancel")
+	}
+}
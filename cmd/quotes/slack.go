@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// slackSecretEnv names the environment variable holding the signing secret,
+// which is kept out of flags so it doesn't show up in process listings
+const slackSecretEnv = "SLACK_SIGNING_SECRET"
+
+// maxRequestSkew is how far a signed request's timestamp may be from the
+// local clock before the request is treated as a replay
+const maxRequestSkew = 5 * time.Minute
+
+// slackMaxBody bounds the size of a slash-command request
+const slackMaxBody = 64 * 1024
+
+var (
+	errBadSignature = errors.New("invalid request signature")
+	errStaleRequest = errors.New("request timestamp is too far from the current time")
+)
+
+var (
+	slackListen    string
+	slackInChannel bool
+)
+
+// newSlackCommand creates the slack subcommand, which answers Slack slash
+// commands
+func newSlackCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "slack",
+		Short: "Answer a Slack slash command such as /quote",
+		Long: `Serve a Slack slash-command endpoint at POST /slack/command.
+
+Point the slash command's Request URL at it and put the app's signing secret in
+the SLACK_SIGNING_SECRET environment variable. Requests without a valid
+signature, or signed more than five minutes away from the local clock, are
+rejected.
+
+  /quote                  a random quote
+  /quote dijkstra         a random quote by a matching author, or with that tag
+  /quote simple code      the best match for the search terms
+  /quote author:<name>    only match authors
+  /quote tag:<tag>        only match tags
+
+Replies are only visible to the person who asked unless --in-channel is set.
+
+SIGTERM or SIGINT shuts the server down gracefully, and SIGHUP reloads the
+collection.`,
+		Args: cobra.NoArgs,
+		RunE: runSlack,
+	}
+
+	cmd.Flags().StringVar(&slackListen, "listen", "localhost:3000", "Address to listen on")
+	cmd.Flags().BoolVar(&slackInChannel, "in-channel", false, "Post replies to the whole channel instead of only to the person who asked")
+
+	return cmd
+}
+
+// runSlack serves the slash-command endpoint until SIGTERM or SIGINT
+func runSlack(cmd *cobra.Command, args []string) error {
+	secret := os.Getenv(slackSecretEnv)
+	if secret == "" {
+		return fmt.Errorf("%s must be set to the Slack app's signing secret", slackSecretEnv)
+	}
+
+	store, err := newQuoteStore(loadFilteredQuotes)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", slackListen)
+	if err != nil {
+		return err
+	}
+
+	handler := newSlackHandler(store, secret, slackInChannel)
+	log.Printf("answering Slack commands with %d quotes on http://%s/slack/command", len(store.Collection().quotes), listener.Addr())
+	return serveUntilSignalled(newHTTPServer(handler), listener, store)
+}
+
+// newSlackHandler answers slash commands signed with secret using quotes
+// from store
+func newSlackHandler(store *quoteStore, secret string, inChannel bool) http.Handler {
+	responseType := "ephemeral"
+	if inChannel {
+		responseType = "in_channel"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /slack/command", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, slackMaxBody))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := verifySlackRequest(secret, r.Header, body, now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var message map[string]interface{}
+		q, err := answerSlackCommand(store.Collection(), form.Get("text"))
+		if err != nil {
+			// Problems are only shown to the person who asked
+			message = map[string]interface{}{"response_type": "ephemeral", "text": err.Error()}
+		} else {
+			message = slackMessage(q, responseType)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(message)
+	})
+	return mux
+}
+
+// verifySlackRequest checks the X-Slack-Signature header, an HMAC-SHA256 of
+// the timestamp and body keyed with the signing secret, and rejects requests
+// whose timestamp is more than maxRequestSkew from now
+func verifySlackRequest(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	if err := checkRequestTime(timestamp, now); err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(want)) {
+		return errBadSignature
+	}
+	return nil
+}
+
+// checkRequestTime rejects a request whose timestamp, in Unix seconds, is
+// missing or more than maxRequestSkew from now
+func checkRequestTime(timestamp string, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errStaleRequest
+	}
+
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > maxRequestSkew || skew < -maxRequestSkew {
+		return errStaleRequest
+	}
+	return nil
+}
+
+// answerSlackCommand picks the quote for the text typed after the command.
+// Bare words are tried as an author, then as a tag, then as search terms.
+func answerSlackCommand(c *collection, text string) (Quote, error) {
+	text = strings.TrimSpace(text)
+	lower := strings.ToLower(text)
+
+	switch {
+	case text == "":
+		return SelectRandom(c.quotes, time.Now().UnixNano())
+	case strings.HasPrefix(lower, "author:"):
+		return randomMatching(c.quotes, Filter{Author: strings.TrimSpace(text[len("author:"):])})
+	case strings.HasPrefix(lower, "tag:"):
+		return randomMatching(c.quotes, Filter{Tag: strings.TrimSpace(text[len("tag:"):])})
+	}
+
+	if q, err := randomMatching(c.quotes, Filter{Author: text}); err == nil {
+		return q, nil
+	}
+	if q, err := randomMatching(c.quotes, Filter{Tag: text}); err == nil {
+		return q, nil
+	}
+
+	results, err := c.index.Search(text, true)
+	if err != nil {
+		return Quote{}, fmt.Errorf("no author, tag or quote matches %q", text)
+	}
+	return results[0].Quote, nil
+}
+
+// randomMatching picks a random quote that satisfies f
+func randomMatching(quotes []Quote, f Filter) (Quote, error) {
+	matching, err := FilterQuotes(quotes, f)
+	if err != nil {
+		return Quote{}, err
+	}
+	return SelectRandom(matching, time.Now().UnixNano())
+}
+
+// slackMessage renders q as a Block Kit message: the quote, then a context
+// line with the attribution, tags and link
+func slackMessage(q Quote, responseType string) map[string]interface{} {
+	return map[string]interface{}{
+		"response_type": responseType,
+		"text":          slackFallback(q),
+		"blocks":        slackBlocks(q),
+	}
+}
+
+// slackFallback is the plain text notifications show in place of q's blocks
+func slackFallback(q Quote) string {
+	return "\"" + slackEscape(q.Text) + "\" — " + slackEscape(q.Author)
+}
+
+// slackBlocks renders q as a section holding the quote and a context block
+// with the attribution, tags and link
+func slackBlocks(q Quote) []map[string]interface{} {
+	byline := "— *" + slackEscape(q.Author) + "*"
+	if q.Source != "" {
+		byline += ", _" + slackEscape(q.Source) + "_"
+	}
+	if q.Year != 0 {
+		byline += " (" + strconv.Itoa(q.Year) + ")"
+	}
+
+	context := []string{byline}
+	if len(q.Tags) > 0 {
+		context = append(context, "`"+slackEscape(strings.Join(q.Tags, "` `"))+"`")
+	}
+	if q.URL != "" {
+		// A | would end the URL and start the link text
+		context = append(context, "<"+slackEscape(strings.ReplaceAll(q.URL, "|", "%7C"))+"|Read more>")
+	}
+
+	return []map[string]interface{}{
+		{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": "> " + strings.ReplaceAll(slackEscape(q.Text), "\n", "\n> ")},
+		},
+		{
+			"type": "context",
+			"elements": []map[string]string{
+				{"type": "mrkdwn", "text": strings.Join(context, "  ·  ")},
+			},
+		},
+	}
+}
+
+// slackEscape escapes the characters Slack's mrkdwn treats as control
+// characters
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSlackSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// signSlack returns the headers Slack would send for body at t
+func signSlack(secret string, body string, t time.Time) http.Header {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	header := http.Header{}
+	header.Set("X-Slack-Request-Timestamp", timestamp)
+	header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestVerifySlackRequest(t *testing.T) {
+	clock := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	body := "command=%2Fquote&text=dijkstra"
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   error
+	}{
+		{name: "valid", header: signSlack(testSlackSecret, body, clock), body: body},
+		{name: "slightly early clock", header: signSlack(testSlackSecret, body, clock.Add(4*time.Minute)), body: body},
+		{name: "tampered body", header: signSlack(testSlackSecret, body, clock), body: body + "x", want: errBadSignature},
+		{name: "wrong secret", header: signSlack("other", body, clock), body: body, want: errBadSignature},
+		{name: "replayed", header: signSlack(testSlackSecret, body, clock.Add(-6*time.Minute)), body: body, want: errStaleRequest},
+		{name: "from the future", header: signSlack(testSlackSecret, body, clock.Add(6*time.Minute)), body: body, want: errStaleRequest},
+		{name: "unsigned", header: http.Header{}, body: body, want: errStaleRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySlackRequest(testSlackSecret, tt.header, []byte(tt.body), clock)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("verifySlackRequest() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswerSlackCommand(t *testing.T) {
+	quotes := append(withIDs(defaultQuotes), withIDs([]Quote{
+		{Text: "Simple things should be simple", Author: "Alan Kay", Tags: []string{"simplicity"}},
+	})...)
+	c := newCollection(quotes)
+
+	tests := []struct {
+		text       string
+		wantAuthor string
+		wantErr    bool
+	}{
+		{text: "dijkstra", wantAuthor: "Edsger Dijkstra"},
+		{text: "author: Torvalds", wantAuthor: "Linus Torvalds"},
+		{text: "Simplicity", wantAuthor: "Alan Kay"},
+		{text: "tag:simplicity", wantAuthor: "Alan Kay"},
+		{text: "tag:nothing", wantErr: true},
+		{text: "debugging twice as hard", wantAuthor: "Brian Kernighan"},
+		{text: "xylophonist", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			q, err := answerSlackCommand(c, tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("answerSlackCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if q.Author != tt.wantAuthor {
+				t.Errorf("answerSlackCommand() author = %q, want %q", q.Author, tt.wantAuthor)
+			}
+		})
+	}
+
+	if q, err := answerSlackCommand(c, ""); err != nil || q.Text == "" {
+		t.Errorf("empty command gave %v, %v; want a random quote", q, err)
+	}
+}
+
+func TestSlackMessage(t *testing.T) {
+	q := Quote{Text: "Use <b> & <i>", Author: "Someone", Source: "A Talk", Year: 1999, Tags: []string{"html"}, URL: "https://example.com"}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(slackMessage(q, "in_channel"))
+	got := buf.String()
+
+	for _, want := range []string{
+		`"response_type":"in_channel"`,
+		`"text":"> Use &lt;b&gt; &amp; &lt;i&gt;"`,
+		`— *Someone*, _A Talk_ (1999)`,
+		"`html`",
+		`<https://example.com|Read more>`,
+		`"text":"\"Use &lt;b&gt; &amp; &lt;i&gt;\" — Someone"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message %s does not contain %s", got, want)
+		}
+	}
+
+	q.URL = "https://example.com/?a=1&b=<x>|<https://evil.example|click>"
+	buf.Reset()
+	enc.Encode(slackMessage(q, "in_channel"))
+	want := `<https://example.com/?a=1&amp;b=&lt;x&gt;%7C&lt;https://evil.example%7Cclick&gt;|Read more>`
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("message %s does not contain the escaped link %s", got, want)
+	}
+}
+
+func TestSlackHandler(t *testing.T) {
+	clock := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return withIDs(defaultQuotes), nil
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		inChannel  bool
+		text       string
+		sign       bool
+		wantStatus int
+		wantType   string
+	}{
+		{name: "ephemeral", text: "dijkstra", sign: true, wantStatus: 200, wantType: "ephemeral"},
+		{name: "in channel", inChannel: true, text: "dijkstra", sign: true, wantStatus: 200, wantType: "in_channel"},
+		{name: "no match stays private", inChannel: true, text: "author:nobody", sign: true, wantStatus: 200, wantType: "ephemeral"},
+		{name: "unsigned", text: "dijkstra", wantStatus: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := url.Values{"command": {"/quote"}, "text": {tt.text}}.Encode()
+			req := httptest.NewRequest(http.MethodPost, "/slack/command", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.sign {
+				for key, values := range signSlack(testSlackSecret, body, clock) {
+					req.Header[key] = values
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			newSlackHandler(store, testSlackSecret, tt.inChannel).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != 200 {
+				return
+			}
+
+			var message map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &message); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if message["response_type"] != tt.wantType {
+				t.Errorf("response_type = %v, want %s", message["response_type"], tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SourceMode says how a quotes file combines with the built-in defaultQuotes
+type SourceMode string
+
+const (
+	// ModeReplace drops the built-in quotes when the file is loaded
+	ModeReplace SourceMode = "replace"
+
+	// ModeAppend adds the file's quotes to the built-in ones
+	ModeAppend SourceMode = "append"
+)
+
+// quotesPathEnv names the environment variable listing extra quote files and
+// directories, separated like PATH
+const quotesPathEnv = "QUOTES_PATH"
+
+// quoteFiles holds the --file flag values
+var quoteFiles []string
+
+// quotesConfigDir returns the quotes directory under $XDG_CONFIG_HOME,
+// defaulting to ~/.config/quotes as the XDG base directory spec requires
+func quotesConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "quotes"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "quotes"), nil
+}
+
+// ResolveSources returns the quote files to load, highest priority first.
+// Explicit files (from --file) are used on their own when given; otherwise
+// the chain is every entry of QUOTES_PATH, then $XDG_CONFIG_HOME/quotes/*.json,
+// then the legacy ~/.quotes.json. Directories expand to the *.json files they
+// contain, in name order. Explicit files must exist, while missing entries in
+// the rest of the chain are skipped.
+func ResolveSources(files []string) ([]string, error) {
+	if len(files) > 0 {
+		var sources []string
+		for _, file := range files {
+			if file == "-" {
+				sources = append(sources, file)
+				continue
+			}
+
+			expanded, err := expandSource(file)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, expanded...)
+		}
+		return sources, nil
+	}
+
+	var candidates []string
+	candidates = append(candidates, filepath.SplitList(os.Getenv(quotesPathEnv))...)
+	if dir, err := quotesConfigDir(); err == nil {
+		candidates = append(candidates, dir)
+	}
+	if path, err := overridePath(); err == nil {
+		candidates = append(candidates, path)
+	}
+
+	var sources []string
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+
+		expanded, err := expandSource(candidate)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, expanded...)
+	}
+
+	return sources, nil
+}
+
+// expandSource returns path itself for a file, or the *.json files inside it
+// for a directory
+func expandSource(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// loadCollection loads every resolved source and combines them: quotes from
+// all sources, highest priority first, followed by the built-in quotes unless
+// any source is in replace mode. Quotes sharing an ID keep only their first,
+// highest priority occurrence.
+// In strict mode any unreadable or invalid source is an error; otherwise it
+// is skipped with a warning.
+func loadCollection(files []string, strict bool) ([]Quote, error) {
+	sources, err := ResolveSources(files)
+	if err != nil {
+		if strict || len(files) > 0 {
+			return nil, err
+		}
+		fmt.Fprintf(warnOutput, "warning: %s, using built-in quotes\n", err)
+		return withIDs(defaultQuotes), nil
+	}
+
+	var quotes []Quote
+	replace := false
+	for _, source := range sources {
+		file, err := ReadQuoteFile(source)
+		if err != nil {
+			if strict {
+				return nil, err
+			}
+			fmt.Fprintf(warnOutput, "warning: ignoring %s:\n%s\n", source, err)
+			continue
+		}
+
+		if file.Mode == ModeReplace {
+			replace = true
+		}
+		quotes = append(quotes, file.Quotes...)
+	}
+
+	if len(quotes) == 0 && len(sources) > 0 {
+		fmt.Fprintln(warnOutput, "warning: no usable quote files, using built-in quotes")
+	}
+
+	if !replace {
+		quotes = append(quotes, withIDs(defaultQuotes)...)
+	}
+
+	return dedupeQuotes(quotes), nil
+}
+
+// dedupeQuotes drops every quote whose ID has already been seen
+func dedupeQuotes(quotes []Quote) []Quote {
+	seen := make(map[string]bool, len(quotes))
+	result := make([]Quote, 0, len(quotes))
+	for _, q := range quotes {
+		if seen[q.ID] {
+			continue
+		}
+		seen[q.ID] = true
+		result = append(result, q)
+	}
+	return result
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// isolateSources points HOME and XDG_CONFIG_HOME at empty temporary
+// directories and clears QUOTES_PATH, returning the home directory
+func isolateSources(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv(quotesPathEnv, "")
+	return home
+}
+
+// writeQuotes writes content to path, creating parent directories
+func writeQuotes(t *testing.T, path, content string) string {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestQuotesConfigDir(t *testing.T) {
+	home := isolateSources(t)
+
+	got, err := quotesConfigDir()
+	if err != nil || got != filepath.Join(home, "config", "quotes") {
+		t.Errorf("quotesConfigDir() = %q, %v; want XDG_CONFIG_HOME/quotes", got, err)
+	}
+
+	// Relative values must be ignored per the XDG spec
+	t.Setenv("XDG_CONFIG_HOME", "relative/config")
+	got, err = quotesConfigDir()
+	if err != nil || got != filepath.Join(home, ".config", "quotes") {
+		t.Errorf("quotesConfigDir() = %q, %v; want ~/.config/quotes", got, err)
+	}
+}
+
+func TestResolveSources(t *testing.T) {
+	home := isolateSources(t)
+
+	shared := filepath.Join(home, "shared")
+	sharedB := writeQuotes(t, filepath.Join(shared, "b.json"), `[]`)
+	sharedA := writeQuotes(t, filepath.Join(shared, "a.json"), `[]`)
+	writeQuotes(t, filepath.Join(shared, "notes.txt"), `ignored`)
+	single := writeQuotes(t, filepath.Join(home, "single.json"), `[]`)
+	xdg := writeQuotes(t, filepath.Join(home, "config", "quotes", "personal.json"), `[]`)
+	legacy := writeQuotes(t, filepath.Join(home, ".quotes.json"), `[]`)
+
+	t.Setenv(quotesPathEnv, strings.Join([]string{single, filepath.Join(home, "missing"), shared}, string(os.PathListSeparator)))
+
+	t.Run("default chain", func(t *testing.T) {
+		got, err := ResolveSources(nil)
+		if err != nil {
+			t.Fatalf("ResolveSources() unexpected error: %v", err)
+		}
+
+		want := []string{single, sharedA, sharedB, xdg, legacy}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveSources() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("explicit files replace the chain", func(t *testing.T) {
+		got, err := ResolveSources([]string{legacy, "-", shared})
+		if err != nil {
+			t.Fatalf("ResolveSources() unexpected error: %v", err)
+		}
+
+		want := []string{legacy, "-", sharedA, sharedB}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveSources() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("explicit files must exist", func(t *testing.T) {
+		_, err := ResolveSources([]string{filepath.Join(home, "missing.json")})
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("ResolveSources() error = %v, want not exist", err)
+		}
+	})
+}
+
+func TestLoadCollection(t *testing.T) {
+	replaceFile := `[{"text": "Replace one", "author": "R"}]`
+	appendFile := `{"mode": "append", "quotes": [{"text": "Append one", "author": "A"}, {"text": "Code is poetry", "author": "Unknown", "tags": ["dup"]}]}`
+
+	tests := []struct {
+		name       string
+		files      map[string]string // relative to home
+		wantLen    int
+		wantFirst  string
+		wantWarn   bool
+		strictFail bool
+	}{
+		{
+			name:      "no sources uses defaults",
+			wantLen:   len(defaultQuotes),
+			wantFirst: defaultQuotes[0].Text,
+		},
+		{
+			name:      "replace source drops defaults",
+			files:     map[string]string{".quotes.json": replaceFile},
+			wantLen:   1,
+			wantFirst: "Replace one",
+		},
+		{
+			name:      "append source keeps defaults and dedupes",
+			files:     map[string]string{"config/quotes/team.json": appendFile},
+			wantLen:   len(defaultQuotes) + 1,
+			wantFirst: "Append one",
+		},
+		{
+			name: "replace and append layer together",
+			files: map[string]string{
+				"config/quotes/team.json": appendFile,
+				".quotes.json":            replaceFile,
+			},
+			wantLen:   3,
+			wantFirst: "Append one",
+		},
+		{
+			name: "invalid source is skipped with a warning",
+			files: map[string]string{
+				"config/quotes/broken.json": `{not json`,
+				".quotes.json":              replaceFile,
+			},
+			wantLen:    1,
+			wantFirst:  "Replace one",
+			wantWarn:   true,
+			strictFail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := isolateSources(t)
+			for path, content := range tt.files {
+				writeQuotes(t, filepath.Join(home, path), content)
+			}
+
+			var warnings strings.Builder
+			warnOutput = &warnings
+			defer func() { warnOutput = os.Stderr }()
+
+			quotes, err := loadCollection(nil, false)
+			if err != nil {
+				t.Fatalf("loadCollection() unexpected error: %v", err)
+			}
+
+			if len(quotes) != tt.wantLen {
+				t.Errorf("loadCollection() returned %d quotes, want %d", len(quotes), tt.wantLen)
+			}
+			if len(quotes) > 0 && quotes[0].Text != tt.wantFirst {
+				t.Errorf("loadCollection() first quote = %q, want %q", quotes[0].Text, tt.wantFirst)
+			}
+			if (warnings.Len() > 0) != tt.wantWarn {
+				t.Errorf("warnings = %q, want warning %v", warnings.String(), tt.wantWarn)
+			}
+
+			if _, err := loadCollection(nil, true); (err != nil) != tt.strictFail {
+				t.Errorf("strict loadCollection() error = %v, want failure %v", err, tt.strictFail)
+			}
+		})
+	}
+}
+
+func TestLoadCollection_DuplicateKeepsHighestPriority(t *testing.T) {
+	home := isolateSources(t)
+	writeQuotes(t, filepath.Join(home, "config", "quotes", "team.json"),
+		`{"mode": "append", "quotes": [{"text": "Code is poetry", "author": "Unknown", "tags": ["team"]}]}`)
+
+	quotes, err := loadCollection(nil, false)
+	if err != nil {
+		t.Fatalf("loadCollection() unexpected error: %v", err)
+	}
+
+	for _, q := range quotes {
+		if q.Text == "Code is poetry" && (len(q.Tags) == 0 || q.Tags[0] != "team") {
+			t.Errorf("duplicate kept the built-in copy instead of the source's: %+v", q)
+		}
+	}
+}
+
+func TestFileFlag(t *testing.T) {
+	home := isolateSources(t)
+	writeQuotes(t, filepath.Join(home, ".quotes.json"), `[{"text": "Legacy", "author": "L"}]`)
+	explicit := writeQuotes(t, filepath.Join(home, "explicit.json"), `[{"text": "Explicit", "author": "E"}]`)
+
+	t.Run("file replaces chain", func(t *testing.T) {
+		output, err := executeCommand(newRootCommand(), "list", "--file", explicit)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(output, "Explicit") || strings.Contains(output, "Legacy") {
+			t.Errorf("output %q should contain only the explicit file", output)
+		}
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		stdin = strings.NewReader(`{"mode": "append", "quotes": [{"text": "Piped", "author": "P"}]}`)
+		defer func() { stdin = os.Stdin }()
+
+		output, err := executeCommand(newRootCommand(), "list", "--file", "-", "--fields", "text")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		if lines[0] != "Piped" || len(lines) != len(defaultQuotes)+1 {
+			t.Errorf("expected piped quote followed by defaults, got %d lines starting %q", len(lines), lines[0])
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := executeCommand(newRootCommand(), "--file", filepath.Join(home, "missing.json"))
+		if err == nil {
+			t.Error("expected error for missing --file")
+		}
+	})
+
+	t.Run("validate chain", func(t *testing.T) {
+		warnOutput = io.Discard
+		defer func() { warnOutput = os.Stderr }()
+
+		output, err := executeCommand(newRootCommand(), "validate")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(output, ".quotes.json: ok (1 quotes, replace)") {
+			t.Errorf("validate output %q should list the legacy file", output)
+		}
+	})
+}
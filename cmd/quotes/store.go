@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sync/atomic"
+)
+
+// collection is an immutable snapshot of the active quotes with the lookup
+// structures servers need
+type collection struct {
+	quotes []Quote
+	byID   map[string]Quote
+	index  *SearchIndex
+}
+
+// newCollection indexes quotes for lookup by ID and for search
+func newCollection(quotes []Quote) *collection {
+	byID := make(map[string]Quote, len(quotes))
+	for _, q := range quotes {
+		byID[q.ID] = q
+	}
+
+	return &collection{
+		quotes: quotes,
+		byID:   byID,
+		index:  NewSearchIndex(quotes),
+	}
+}
+
+// quoteStore holds the collection served by long-running commands. Reload
+// swaps in a new snapshot atomically, so requests already in flight finish
+// against the snapshot they started with.
+type quoteStore struct {
+	current atomic.Pointer[collection]
+	load    func() ([]Quote, error)
+}
+
+// newQuoteStore creates a store that fills itself with load, which is also
+// used for every later Reload
+func newQuoteStore(load func() ([]Quote, error)) (*quoteStore, error) {
+	s := &quoteStore{load: load}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Collection returns the current snapshot
+func (s *quoteStore) Collection() *collection {
+	return s.current.Load()
+}
+
+// Reload loads the collection again. On error the previous snapshot stays
+// in place.
+func (s *quoteStore) Reload() error {
+	quotes, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	s.current.Store(newCollection(quotes))
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQuoteStore(t *testing.T) {
+	quotes := withIDs(defaultQuotes)
+	var loadErr error
+	loaded := quotes[:3]
+
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return loaded, loadErr
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+
+	first := store.Collection()
+	if len(first.quotes) != 3 {
+		t.Fatalf("Collection() has %d quotes, want 3", len(first.quotes))
+	}
+	if _, ok := first.byID[quotes[0].ID]; !ok {
+		t.Errorf("byID is missing %s", quotes[0].ID)
+	}
+
+	t.Run("reload swaps the snapshot", func(t *testing.T) {
+		loaded = quotes[:5]
+		if err := store.Reload(); err != nil {
+			t.Fatalf("Reload() unexpected error: %v", err)
+		}
+		if got := len(store.Collection().quotes); got != 5 {
+			t.Errorf("Collection() has %d quotes after reload, want 5", got)
+		}
+		if len(first.quotes) != 3 {
+			t.Errorf("old snapshot changed to %d quotes", len(first.quotes))
+		}
+	})
+
+	t.Run("failed reload keeps the snapshot", func(t *testing.T) {
+		loadErr = errors.New("broken file")
+		if err := store.Reload(); err == nil {
+			t.Fatal("Reload() expected error, got nil")
+		}
+		if got := len(store.Collection().quotes); got != 5 {
+			t.Errorf("Collection() has %d quotes after failed reload, want 5", got)
+		}
+	})
+}
+
+func TestNewQuoteStore_LoadError(t *testing.T) {
+	_, err := newQuoteStore(func() ([]Quote, error) {
+		return nil, ErrNoMatches
+	})
+	if !errors.Is(err, ErrNoMatches) {
+		t.Errorf("newQuoteStore() error = %v, want %v", err, ErrNoMatches)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// minStreamInterval stops clients asking for a flood of events
+const minStreamInterval = time.Second
+
+var (
+	streamListen    string
+	streamInterval  time.Duration
+	streamHeartbeat time.Duration
+)
+
+// streamConfig controls the events a stream handler sends
+type streamConfig struct {
+	interval  time.Duration
+	heartbeat time.Duration
+	seed      int64
+}
+
+// newStreamCommand creates the stream subcommand, which pushes rotating
+// quotes to clients as Server-Sent Events
+func newStreamCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stream",
+		Short: "Stream rotating quotes as Server-Sent Events",
+		Long: `Serve a Server-Sent Events stream at GET /stream that sends a quote as soon as
+a client connects and a new one every interval.
+
+Quotes are dealt like "quotes --count": a shuffled pass through the matching
+quotes, then another, so a quote doesn't repeat until every other one has been
+shown. Each event's ID is its position in that sequence, and clients that
+reconnect with Last-Event-ID carry on where they left off.
+
+Query parameters: the filters author, exact_author, min_length, max_length,
+match and tag, format (json, text, markdown or html) and interval (e.g. 30s).
+
+SIGTERM or SIGINT closes the streams and shuts the server down, and SIGHUP
+reloads the collection.`,
+		Args: cobra.NoArgs,
+		RunE: runStream,
+	}
+
+	cmd.Flags().StringVar(&streamListen, "listen", "localhost:8080", "Address to listen on")
+	cmd.Flags().DurationVar(&streamInterval, "interval", time.Minute, "Default time between quotes")
+	cmd.Flags().DurationVar(&streamHeartbeat, "heartbeat", 15*time.Second, "Time between keep-alive comments (0 to disable)")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "Seed for the quote sequence, so resumed streams survive restarts (default random)")
+
+	return cmd
+}
+
+// runStream serves the event stream until SIGTERM or SIGINT
+func runStream(cmd *cobra.Command, args []string) error {
+	if streamInterval < minStreamInterval {
+		return fmt.Errorf("--interval must be at least %s", minStreamInterval)
+	}
+	if streamHeartbeat < 0 {
+		return fmt.Errorf("--heartbeat must not be negative")
+	}
+
+	store, err := newQuoteStore(loadFilteredQuotes)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", streamListen)
+	if err != nil {
+		return err
+	}
+
+	config := streamConfig{interval: streamInterval, heartbeat: streamHeartbeat, seed: seed}
+	if config.seed == 0 {
+		config.seed = time.Now().UnixNano()
+	}
+
+	// Streams never finish on their own, so end them when shutdown starts
+	stopping := make(chan struct{})
+	srv := newHTTPServer(newStreamHandler(store, config, stopping))
+	srv.RegisterOnShutdown(func() { close(stopping) })
+
+	log.Printf("streaming %d quotes on http://%s/stream", len(store.Collection().quotes), listener.Addr())
+	return serveUntilSignalled(srv, listener, store)
+}
+
+// newStreamHandler serves the event stream from store. Open streams end when
+// stopping is closed.
+func newStreamHandler(store *quoteStore, config streamConfig, stopping <-chan struct{}) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stream", func(w http.ResponseWriter, r *http.Request) {
+		handleStream(w, r, store, config, stopping)
+	})
+	return mux
+}
+
+// handleStream validates the request, then sends events until the client
+// disconnects or the server stops
+func handleStream(w http.ResponseWriter, r *http.Request, store *quoteStore, config streamConfig, stopping <-chan struct{}) {
+	params := r.URL.Query()
+
+	filter, err := filterFromParams(params)
+	if err != nil {
+		writeError(w, "text", err)
+		return
+	}
+
+	format := params.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if !isValidFormat(format) {
+		writeError(w, "text", badRequest("invalid format: %s (must be one of: text, json, markdown, html, say)", format))
+		return
+	}
+
+	interval := config.interval
+	if value := params.Get("interval"); value != "" {
+		if interval, err = time.ParseDuration(value); err != nil || interval < minStreamInterval {
+			writeError(w, "text", badRequest("interval must be a duration of at least %s, got %q", minStreamInterval, value))
+			return
+		}
+	}
+
+	next := 0
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		last, err := strconv.Atoi(lastID)
+		// The IDs that follow must not overflow
+		if err != nil || last < 0 || last >= math.MaxInt-1 {
+			writeError(w, "text", badRequest("invalid Last-Event-ID: %q", lastID))
+			return
+		}
+		next = last + 1
+	}
+
+	if _, err := FilterQuotes(store.Collection().quotes, filter); err != nil {
+		writeError(w, "text", &httpError{status: http.StatusNotFound, message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	send := func() error {
+		// Filter the current snapshot each time so a reload takes effect
+		// without reconnecting
+		quotes, err := FilterQuotes(store.Collection().quotes, filter)
+		if err != nil {
+			fmt.Fprintf(w, ": %s\n\n", err)
+		} else {
+			writeEvent(w, next, format, streamQuote(quotes, next, config.seed))
+			next++
+		}
+		return rc.Flush()
+	}
+
+	if send() != nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// A nil channel never fires, which disables heartbeats
+	var heartbeat <-chan time.Time
+	if config.heartbeat > 0 {
+		heartbeatTicker := time.NewTicker(config.heartbeat)
+		defer heartbeatTicker.Stop()
+		heartbeat = heartbeatTicker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-stopping:
+			return
+		case <-ticker.C:
+			if send() != nil {
+				return
+			}
+		case <-heartbeat:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			if rc.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamQuote returns the quote at position n of the stream's sequence. The
+// sequence deals shuffled passes through quotes with SelectQuotes, one seed
+// per pass, so any position can be found without replaying the ones before.
+func streamQuote(quotes []Quote, n int, seed int64) Quote {
+	pass := n / len(quotes)
+	shuffled, _ := SelectQuotes(quotes, len(quotes), passSeed(seed, pass), false)
+	return shuffled[n%len(quotes)]
+}
+
+// passSeed derives the seed of one pass of a stream by hashing the stream's
+// seed with the pass number, so neighbouring passes and streams whose seeds
+// are close together shuffle independently
+func passSeed(seed int64, pass int) int64 {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(seed))
+	binary.BigEndian.PutUint64(buf[8:], uint64(pass))
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return int64(h.Sum64())
+}
+
+// writeEvent writes q as a "quote" event. JSON data is a single compact
+// object; text and markdown span one data line per output line.
+func writeEvent(w io.Writer, id int, format string, q Quote) {
+	var data string
+	if format == "json" {
+		encoded, _ := json.Marshal(q)
+		data = string(encoded)
+	} else {
+		data = strings.TrimRight(formatQuotes(format, []Quote{q}), "\n")
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: quote\n", id)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	fmt.Fprint(w, "\n")
+}
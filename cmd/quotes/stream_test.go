@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamQuote(t *testing.T) {
+	quotes := withIDs(defaultQuotes)[:5]
+
+	// Each pass through the collection shows every quote exactly once
+	for pass := 0; pass < 3; pass++ {
+		seen := make(map[string]bool)
+		for i := 0; i < len(quotes); i++ {
+			q := streamQuote(quotes, pass*len(quotes)+i, 42)
+			if seen[q.ID] {
+				t.Errorf("pass %d repeats %q", pass, q.Text)
+			}
+			seen[q.ID] = true
+		}
+	}
+
+	if streamQuote(quotes, 7, 42).ID != streamQuote(quotes, 7, 42).ID {
+		t.Error("streamQuote() is not deterministic for the same position and seed")
+	}
+}
+
+func TestPassSeed(t *testing.T) {
+	if passSeed(42, 3) != passSeed(42, 3) {
+		t.Error("passSeed() is not deterministic")
+	}
+
+	// A stream's next pass must not reuse the seed of a stream one seed along
+	seen := make(map[int64]string)
+	for seed := int64(40); seed < 45; seed++ {
+		for pass := 0; pass < 5; pass++ {
+			got := passSeed(seed, pass)
+			key := strconv.FormatInt(seed, 10) + "/" + strconv.Itoa(pass)
+			if prev, ok := seen[got]; ok {
+				t.Errorf("passSeed(%s) = passSeed(%s)", key, prev)
+			}
+			seen[got] = key
+		}
+	}
+}
+
+func TestWriteEvent(t *testing.T) {
+	q := Quote{ID: "abc12345", Text: "Hello", Author: "World"}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "json", want: "id: 3\nevent: quote\ndata: {\"id\":\"abc12345\",\"text\":\"Hello\",\"author\":\"World\"}\n\n"},
+		{format: "text", want: "id: 3\nevent: quote\ndata: Hello\ndata:    - World\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeEvent(&buf, 3, tt.format, q)
+			if buf.String() != tt.want {
+				t.Errorf("writeEvent() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// streamEvent is one event read back from a stream
+type streamEvent struct {
+	id      string
+	comment string
+	data    string
+}
+
+// readEvent reads the next event or comment block from r
+func readEvent(t *testing.T, r *bufio.Reader) streamEvent {
+	t.Helper()
+	var event streamEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return event
+		case strings.HasPrefix(line, ": "):
+			event.comment = strings.TrimPrefix(line, ": ")
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data += strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func newTestStream(t *testing.T, config streamConfig) (*httptest.Server, chan struct{}) {
+	t.Helper()
+	store, err := newQuoteStore(func() ([]Quote, error) {
+		return withIDs(defaultQuotes), nil
+	})
+	if err != nil {
+		t.Fatalf("newQuoteStore() unexpected error: %v", err)
+	}
+
+	stopping := make(chan struct{})
+	srv := httptest.NewServer(newStreamHandler(store, config, stopping))
+	t.Cleanup(srv.Close)
+	return srv, stopping
+}
+
+func openStream(t *testing.T, url, lastID string) *bufio.Reader {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestStreamHandler(t *testing.T) {
+	srv, _ := newTestStream(t, streamConfig{interval: 10 * time.Millisecond, seed: 1})
+
+	stream := openStream(t, srv.URL+"/stream?author=dijkstra", "")
+	for i := 0; i < 3; i++ {
+		event := readEvent(t, stream)
+		if event.id != strconv.Itoa(i) {
+			t.Errorf("event %d has id %q", i, event.id)
+		}
+
+		var q Quote
+		if err := json.Unmarshal([]byte(event.data), &q); err != nil {
+			t.Fatalf("invalid event data %q: %v", event.data, err)
+		}
+		if !strings.Contains(q.Author, "Dijkstra") {
+			t.Errorf("event %d is by %q, want Dijkstra", i, q.Author)
+		}
+	}
+}
+
+func TestStreamHandler_Resume(t *testing.T) {
+	srv, _ := newTestStream(t, streamConfig{interval: time.Hour, seed: 1})
+
+	first := openStream(t, srv.URL+"/stream", "")
+	readEvent(t, first)
+
+	// A fresh connection would start at 0; resuming from 4 continues at 5
+	want := streamQuote(withIDs(defaultQuotes), 5, 1)
+	event := readEvent(t, openStream(t, srv.URL+"/stream", "4"))
+	if event.id != "5" {
+		t.Errorf("resumed event id = %q, want 5", event.id)
+	}
+	if !strings.Contains(event.data, want.ID) {
+		t.Errorf("resumed event data = %q, want quote %s", event.data, want.ID)
+	}
+}
+
+func TestStreamHandler_Heartbeat(t *testing.T) {
+	srv, _ := newTestStream(t, streamConfig{interval: time.Hour, heartbeat: 10 * time.Millisecond, seed: 1})
+
+	stream := openStream(t, srv.URL+"/stream", "")
+	readEvent(t, stream)
+	if event := readEvent(t, stream); event.comment != "heartbeat" {
+		t.Errorf("second block = %+v, want a heartbeat", event)
+	}
+}
+
+func TestStreamHandler_Stopping(t *testing.T) {
+	srv, stopping := newTestStream(t, streamConfig{interval: time.Hour, seed: 1})
+
+	stream := openStream(t, srv.URL+"/stream", "")
+	readEvent(t, stream)
+	close(stopping)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := stream.ReadString('\n')
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("stream kept sending after stopping")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after stopping")
+	}
+}
+
+func TestStreamHandler_BadRequest(t *testing.T) {
+	srv, _ := newTestStream(t, streamConfig{interval: time.Hour, seed: 1})
+
+	tests := []struct {
+		name   string
+		target string
+		lastID string
+		want   int
+	}{
+		{name: "invalid interval", target: "/stream?interval=10ms", want: http.StatusBadRequest},
+		{name: "invalid format", target: "/stream?format=yaml", want: http.StatusBadRequest},
+		{name: "invalid last event id", target: "/stream", lastID: "abc", want: http.StatusBadRequest},
+		{name: "negative last event id", target: "/stream", lastID: "-1", want: http.StatusBadRequest},
+		{name: "overflowing last event id", target: "/stream", lastID: strconv.Itoa(math.MaxInt), want: http.StatusBadRequest},
+		{name: "invalid filter", target: "/stream?match=(", want: http.StatusBadRequest},
+		{name: "no matches", target: "/stream?author=nobody", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.target, nil)
+			if tt.lastID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET %s: %v", tt.target, err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFormatPrefix marks a --format value naming a saved template
+const templateFormatPrefix = "tmpl:"
+
+// templateExt is the extension of templates in the templates directory
+const templateExt = ".tmpl"
+
+// templateData is what output and subject templates are executed with
+type templateData struct {
+	// Date is when the template is executed
+	Date time.Time
+
+	// Quotes holds the selected quotes, and Quote the first of them
+	Quotes []Quote
+	Quote  Quote
+	Count  int
+}
+
+// newTemplateData returns the data for executing a template over quotes
+func newTemplateData(quotes []Quote, date time.Time) templateData {
+	data := templateData{Date: date, Quotes: quotes, Count: len(quotes)}
+	if len(quotes) > 0 {
+		data.Quote = quotes[0]
+	}
+	return data
+}
+
+// templateFuncs are the helpers available to templates, alongside the
+// text/template built-ins such as index, len and printf. Functions taking a
+// width or separator take it first so they work at the end of a pipeline.
+var templateFuncs = template.FuncMap{
+	"wrap":     wrapText,
+	"truncate": truncateText,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"join":     func(sep string, items []string) string { return strings.Join(items, sep) },
+	"escape":   html.EscapeString,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// templatesDir returns the directory tmpl:name formats are loaded from
+func templatesDir() (string, error) {
+	dir, err := quotesConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// isTemplateFormat reports whether format names a saved template
+func isTemplateFormat(format string) bool {
+	return strings.HasPrefix(format, templateFormatPrefix)
+}
+
+// loadTemplate parses the output template given by source, which is inline
+// template text if it contains "{{" and a file path otherwise
+func loadTemplate(source string) (*template.Template, error) {
+	if strings.Contains(source, "{{") {
+		tmpl, err := parseTemplate("inline", source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --template: %w", err)
+		}
+		return tmpl, nil
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read template: %w", err)
+	}
+	tmpl, err := parseTemplate(filepath.Base(source), string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", source, err)
+	}
+	return tmpl, nil
+}
+
+// loadNamedTemplate parses the template saved as name in the templates
+// directory
+func loadNamedTemplate(name string) (*template.Template, error) {
+	dir, err := templatesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	if !isPlainName(name) {
+		return nil, fmt.Errorf("invalid template name: %q", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+templateExt))
+	if errors.Is(err, os.ErrNotExist) {
+		available := savedNames(dir, templateExt)
+		if len(available) == 0 {
+			return nil, fmt.Errorf("no template named %q: %s has no %s files", name, dir, templateExt)
+		}
+		return nil, fmt.Errorf("no template named %q in %s (available: %s)", name, dir, strings.Join(available, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := parseTemplate(name, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// isPlainName reports whether name can name a file in a config directory
+// without reaching outside it
+func isPlainName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\`) && name == filepath.Base(name)
+}
+
+// savedNames lists the names of the files in dir with the extension ext
+func savedNames(dir, ext string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"+ext))
+	names := make([]string, len(matches))
+	for i, match := range matches {
+		names[i] = strings.TrimSuffix(filepath.Base(match), ext)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseTemplate parses text as a template with the helper functions
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// executeTemplate renders quotes with tmpl
+func executeTemplate(tmpl *template.Template, quotes []Quote, date time.Time) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, newTemplateData(quotes, date)); err != nil {
+		return "", fmt.Errorf("template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// wrapText breaks text into lines at most width columns wide at spaces,
+// keeping existing line breaks
+func wrapText(width int, text string) string {
+	return strings.Join(wrapLines(width, text), "\n")
+}
+
+// truncateText shortens text to at most length characters, ending with an
+// ellipsis when anything was cut
+func truncateText(length int, text string) string {
+	runes := []rune(text)
+	if length < 1 || len(runes) <= length {
+		return text
+	}
+	return strings.TrimRight(string(runes[:length-1]), " ") + "…"
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		text  string
+		want  string
+	}{
+		{"fits", 20, "Talk is cheap.", "Talk is cheap."},
+		{"breaks at spaces", 10, "Talk is cheap. Show me the code.", "Talk is\ncheap.\nShow me\nthe code."},
+		{"keeps line breaks", 20, "First line\nsecond line", "First line\nsecond line"},
+		{"long word", 4, "a supercalifragilistic b", "a\nsupercalifragilistic\nb"},
+		{"counts runes", 7, "café au lait", "café au\nlait"},
+		{"no width", 0, "left  alone", "left  alone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapText(tt.width, tt.text); got != tt.want {
+				t.Errorf("wrapText(%d, %q) = %q, want %q", tt.width, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		length int
+		text   string
+		want   string
+	}{
+		{20, "Talk is cheap.", "Talk is cheap."},
+		{8, "Talk is cheap.", "Talk is…"},
+		{9, "Talk is cheap.", "Talk is…"},
+		{5, "naïveté", "naïv…"},
+		{0, "unchanged", "unchanged"},
+	}
+
+	for _, tt := range tests {
+		if got := truncateText(tt.length, tt.text); got != tt.want {
+			t.Errorf("truncateText(%d, %q) = %q, want %q", tt.length, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteTemplate(t *testing.T) {
+	quotes := []Quote{
+		{ID: "1", Text: "Talk is cheap. Show me the code.", Author: "Linus Torvalds", Tags: []string{"code", "talk"}},
+		{ID: "2", Text: "Less is <more>.", Author: "Mies"},
+	}
+	date := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{"range", `{{range .Quotes}}{{.Author}}: {{.Text}}{{"\n"}}{{end}}`, "Linus Torvalds: Talk is cheap. Show me the code.\nMies: Less is <more>.\n", false},
+		{"first quote and count", `{{.Quote.Author | upper}} ({{.Count}})`, "LINUS TORVALDS (2)", false},
+		{"index", `{{(index .Quotes 1).Author}}`, "Mies", false},
+		{"wrap", `{{wrap 14 .Quote.Text}}`, "Talk is cheap.\nShow me the\ncode.", false},
+		{"truncate", `{{.Quote.Text | truncate 8}}`, "Talk is…", false},
+		{"escape", `{{(index .Quotes 1).Text | escape}}`, "Less is &lt;more&gt;.", false},
+		{"json", `{{json .Quote.Tags}}`, `["code","talk"]`, false},
+		{"join", `{{join ", " .Quote.Tags}}`, "code, talk", false},
+		{"date", `{{.Date.Format "2006-01-02"}}`, "2026-10-19", false},
+		{"unknown field", `{{.Quote.Colour}}`, "", true},
+		{"index out of range", `{{index .Quotes 5}}`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseTemplate(tt.name, tt.template)
+			if err != nil {
+				t.Fatalf("parseTemplate() unexpected error: %v", err)
+			}
+
+			got, err := executeTemplate(tmpl, quotes, date)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("executeTemplate() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("executeTemplate() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("executeTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadNamedTemplate(t *testing.T) {
+	home := isolateSources(t)
+	dir := filepath.Join(home, "config", "quotes", "templates")
+	writeQuotes(t, filepath.Join(dir, "tweet.tmpl"), `{{.Quote.Text}} —{{.Quote.Author}}`)
+	writeQuotes(t, filepath.Join(dir, "broken.tmpl"), `{{.Quote.Text`)
+
+	if _, err := loadNamedTemplate("tweet"); err != nil {
+		t.Errorf("loadNamedTemplate(tweet) unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{"missing", "available: broken, tweet"},
+		{"broken", "invalid template broken"},
+		{"../tweet", "invalid template name"},
+		{"", "invalid template name"},
+	}
+
+	for _, tt := range tests {
+		_, err := loadNamedTemplate(tt.name)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("loadNamedTemplate(%q) error = %v, want it to contain %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTemplateCommand(t *testing.T) {
+	home := isolateSources(t)
+	writeQuotes(t, filepath.Join(home, "config", "quotes", "templates", "tweet.tmpl"), "{{range .Quotes}}#{{.Author | lower}}\n{{end}}")
+	file := writeQuotes(t, filepath.Join(home, "layout.tmpl"), "{{.Count}} from {{.Quote.Author}}")
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "inline",
+			args: []string{"today", "--date", "2026-10-19", "--author", "dijkstra", "--template", "{{.Quote.Author}}"},
+			want: "Edsger Dijkstra\n",
+		},
+		{
+			name: "file",
+			args: []string{"-n", "2", "--author", "dijkstra", "--allow-repeats", "--template", file},
+			want: "2 from Edsger Dijkstra\n",
+		},
+		{
+			name: "named",
+			args: []string{"list", "--author", "dijkstra", "--limit", "1", "--format", "tmpl:tweet"},
+			want: "#edsger dijkstra\n",
+		},
+		{
+			name: "search",
+			args: []string{"search", "--limit", "1", "--template", "{{len .Quotes}}", "code"},
+			want: "1\n",
+		},
+		{
+			name:    "unknown name",
+			args:    []string{"--format", "tmpl:thread"},
+			wantErr: `no template named "thread"`,
+		},
+		{
+			name:    "invalid inline",
+			args:    []string{"--template", "{{.Quote"},
+			wantErr: "invalid --template",
+		},
+		{
+			name:    "missing file",
+			args:    []string{"--template", filepath.Join(home, "missing.tmpl")},
+			wantErr: "cannot read template",
+		},
+		{
+			name:    "standalone",
+			args:    []string{"--template", "{{.Count}}", "--standalone"},
+			wantErr: "--standalone",
+		},
+		{
+			name:    "list fields",
+			args:    []string{"list", "--template", "{{.Count}}", "--fields", "id"},
+			wantErr: "--fields",
+		},
+		{
+			name:    "search score",
+			args:    []string{"search", "--template", "{{.Count}}", "--score", "code"},
+			wantErr: "--score",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := executeCommand(newRootCommand(), tt.args...)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package main
+
+import "os"
+
+// terminalWidth reports whether f is a terminal. The width isn't known here,
+// so it is always 0.
+func terminalWidth(f *os.File) (int, bool) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, false
+	}
+	return 0, info.Mode()&os.ModeCharDevice != 0
+}
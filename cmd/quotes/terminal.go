@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	textWidth      int
+	textColor      string
+	textColorTheme string
+	textFrame      string
+)
+
+// defaultTerminalWidth is used when a terminal doesn't report its size
+const defaultTerminalWidth = 80
+
+// colorTheme holds the SGR parameters each part of text output is drawn with.
+// An empty parameter leaves that part uncoloured.
+type colorTheme struct {
+	text, author, meta, frame string
+}
+
+// colorThemes holds the --color-theme choices
+var colorThemes = map[string]colorTheme{
+	"default": {text: "1", author: "36", meta: "2", frame: "2"},
+	"warm":    {text: "1;33", author: "31", meta: "2;33", frame: "33"},
+	"cool":    {text: "1;34", author: "36", meta: "2;36", frame: "34"},
+	"plain":   {text: "1", author: "3", meta: "2"},
+}
+
+// frameStyle holds the characters a box is drawn with
+type frameStyle struct {
+	topLeft, topRight, bottomLeft, bottomRight, horizontal, vertical string
+}
+
+// frameStyles holds the --frame choices
+var frameStyles = map[string]frameStyle{
+	"none":    {},
+	"single":  {"┌", "┐", "└", "┘", "─", "│"},
+	"double":  {"╔", "╗", "╚", "╝", "═", "║"},
+	"rounded": {"╭", "╮", "╰", "╯", "─", "│"},
+	"ascii":   {"+", "+", "+", "+", "-", "|"},
+}
+
+// textStyle is how text output is laid out. The zero value is plain
+// FormatText output.
+type textStyle struct {
+	// width wraps lines to this many columns, or not at all when 0
+	width int
+
+	// colors is nil when output isn't coloured
+	colors *colorTheme
+	frame  frameStyle
+}
+
+// textLine is a line of text output: an indent, followed by content that is
+// coloured as role
+type textLine struct {
+	indent  string
+	content string
+	role    string
+}
+
+// addTextFlags registers the flags that style text output
+func addTextFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&textWidth, "width", 0, "Wrap text output to this many columns (default: the terminal width)")
+	cmd.Flags().StringVar(&textColor, "color", "auto", "Colour text output: auto|always|never")
+	cmd.Flags().StringVar(&textColorTheme, "color-theme", "default", "Colours of text output: "+strings.Join(styleNames(colorThemes), "|"))
+	cmd.Flags().StringVar(&textFrame, "frame", "none", "Draw a box around each quote: "+strings.Join(styleNames(frameStyles), "|"))
+}
+
+// newTextStyle validates the text flags and resolves them against f, the
+// file output is written to. Wrapping and colour are on by default only when
+// f is a terminal, and NO_COLOR turns colour off unless --color always is
+// given.
+func newTextStyle(f *os.File, format string) (textStyle, error) {
+	var style textStyle
+
+	theme, ok := colorThemes[textColorTheme]
+	if !ok {
+		return style, fmt.Errorf("invalid color theme: %s (must be one of: %s)", textColorTheme, strings.Join(styleNames(colorThemes), ", "))
+	}
+	if style.frame, ok = frameStyles[textFrame]; !ok {
+		return style, fmt.Errorf("invalid frame: %s (must be one of: %s)", textFrame, strings.Join(styleNames(frameStyles), ", "))
+	}
+	if textWidth < 0 {
+		return style, fmt.Errorf("--width must not be negative")
+	}
+	if format != "text" && textFrame != "none" {
+		return style, fmt.Errorf("--frame needs --format text")
+	}
+	if format != "text" && format != "say" && textWidth != 0 {
+		return style, fmt.Errorf("--width needs --format text or say")
+	}
+
+	width, isTerminal := terminalWidth(f)
+
+	switch textColor {
+	case "always":
+		style.colors = &theme
+	case "auto":
+		if isTerminal && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" {
+			style.colors = &theme
+		}
+	case "never":
+	default:
+		return style, fmt.Errorf("invalid color: %s (must be one of: auto, always, never)", textColor)
+	}
+
+	switch {
+	case textWidth > 0:
+		style.width = textWidth
+	case isTerminal:
+		style.width = width
+		if style.width == 0 {
+			style.width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+		}
+		if style.width <= 0 {
+			style.width = defaultTerminalWidth
+		}
+	}
+
+	return style, nil
+}
+
+// isPlain reports whether the style leaves FormatText output unchanged
+func (s textStyle) isPlain() bool {
+	return s.width == 0 && s.colors == nil && s.frame == (frameStyle{})
+}
+
+// renderText formats quotes like FormatText, wrapped, coloured and framed as
+// the style asks
+func renderText(quotes []Quote, style textStyle) string {
+	blocks := make([][]textLine, len(quotes))
+	for i, q := range quotes {
+		prefix := ""
+		if len(quotes) > 1 {
+			prefix = fmt.Sprintf("%d. ", i+1)
+		}
+		blocks[i] = style.quoteLines(q, prefix, nil)
+	}
+	return style.render(blocks)
+}
+
+// quoteLines lays out a quote as its text, after prefix, then its
+// attribution with a hanging indent, its metadata and any extra lines
+func (s textStyle) quoteLines(q Quote, prefix string, extra []string) []textLine {
+	width := s.width
+	if width > 0 && s.frame.vertical != "" {
+		// Leave room for the border and its padding on either side
+		width = max(width-4, 1)
+	}
+
+	var lines []textLine
+	add := func(first, rest, text, role string) {
+		for i, line := range wrapLines(width-displayWidth(rest), text) {
+			indent := rest
+			if i == 0 {
+				indent = first
+			}
+			lines = append(lines, textLine{indent: indent, content: line, role: role})
+		}
+	}
+
+	add(prefix, strings.Repeat(" ", displayWidth(prefix)), q.Text, "text")
+	add("   - ", "     ", attribution(q, q.Source), "author")
+	for _, line := range append(metadataLines(q), extra...) {
+		add("     ", "     ", line, "meta")
+	}
+	return lines
+}
+
+// render draws blocks of lines, each in its own frame if the style has one
+func (s textStyle) render(blocks [][]textLine) string {
+	var result strings.Builder
+
+	if s.frame.vertical == "" {
+		for _, lines := range blocks {
+			for _, line := range lines {
+				result.WriteString(line.indent + s.paint(line.role, line.content) + "\n")
+			}
+		}
+		return result.String()
+	}
+
+	// Size every frame to the widest line so stacked frames line up
+	inner := 0
+	for _, lines := range blocks {
+		for _, line := range lines {
+			inner = max(inner, displayWidth(line.indent+line.content))
+		}
+	}
+
+	for _, lines := range blocks {
+		border := strings.Repeat(s.frame.horizontal, inner+2)
+		vertical := s.paint("frame", s.frame.vertical)
+		result.WriteString(s.paint("frame", s.frame.topLeft+border+s.frame.topRight) + "\n")
+		for _, line := range lines {
+			padding := strings.Repeat(" ", inner-displayWidth(line.indent+line.content))
+			fmt.Fprintf(&result, "%s %s%s%s %s\n", vertical, line.indent, s.paint(line.role, line.content), padding, vertical)
+		}
+		result.WriteString(s.paint("frame", s.frame.bottomLeft+border+s.frame.bottomRight) + "\n")
+	}
+
+	return result.String()
+}
+
+// paint colours text as role when the style is coloured
+func (s textStyle) paint(role, text string) string {
+	if s.colors == nil || text == "" {
+		return text
+	}
+
+	var code string
+	switch role {
+	case "text":
+		code = s.colors.text
+	case "author":
+		code = s.colors.author
+	case "meta":
+		code = s.colors.meta
+	case "frame":
+		code = s.colors.frame
+	}
+	if code == "" {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
+
+// styleNames returns the keys of a style map in alphabetical order
+func styleNames[T any](styles map[string]T) []string {
+	names := make([]string, 0, len(styles))
+	for name := range styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderText(t *testing.T) {
+	cook := Quote{Text: "Programming today is a race between software engineers striving to build bigger and better idiot-proof programs", Author: "Rick Cook", Source: "The Wizardry Compiled", Year: 1989}
+	short := Quote{Text: "Code is poetry", Author: "Unknown", Tags: []string{"code"}}
+	theme := colorThemes["default"]
+
+	tests := []struct {
+		name   string
+		quotes []Quote
+		style  textStyle
+		want   string
+	}{
+		{
+			name:   "wrapped with hanging indent",
+			quotes: []Quote{cook},
+			style:  textStyle{width: 30},
+			want: "Programming today is a race\n" +
+				"between software engineers\n" +
+				"striving to build bigger and\n" +
+				"better idiot-proof programs\n" +
+				"   - Rick Cook, The Wizardry\n" +
+				"     Compiled (1989)\n",
+		},
+		{
+			name:   "numbered",
+			quotes: []Quote{short, short},
+			style:  textStyle{width: 12},
+			want:   "1. Code is\n   poetry\n   - Unknown\n     Tags:\n     code\n2. Code is\n   poetry\n   - Unknown\n     Tags:\n     code\n",
+		},
+		{
+			name:   "coloured",
+			quotes: []Quote{short},
+			style:  textStyle{colors: &theme},
+			want:   "\x1b[1mCode is poetry\x1b[0m\n   - \x1b[36mUnknown\x1b[0m\n     \x1b[2mTags: code\x1b[0m\n",
+		},
+		{
+			name:   "framed",
+			quotes: []Quote{{Text: "日本語", Author: "Unknown"}},
+			style:  textStyle{frame: frameStyles["rounded"]},
+			want:   "╭──────────────╮\n│ 日本語       │\n│    - Unknown │\n╰──────────────╯\n",
+		},
+		{
+			name:   "framed within width",
+			quotes: []Quote{{Text: "Talk is cheap. Show me the code.", Author: "Linus"}},
+			style:  textStyle{width: 20, frame: frameStyles["ascii"]},
+			want:   "+----------------+\n| Talk is cheap. |\n| Show me the    |\n| code.          |\n|    - Linus     |\n+----------------+\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderText(tt.quotes, tt.style); got != tt.want {
+				t.Errorf("renderText() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("frames line up", func(t *testing.T) {
+		got := renderText([]Quote{short, cook}, textStyle{width: 40, frame: frameStyles["single"]})
+		lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+		for _, line := range lines {
+			if displayWidth(line) != displayWidth(lines[0]) {
+				t.Errorf("line %q is %d columns, want %d", line, displayWidth(line), displayWidth(lines[0]))
+			}
+		}
+		if displayWidth(lines[0]) > 40 {
+			t.Errorf("frame is %d columns, want at most 40", displayWidth(lines[0]))
+		}
+	})
+}
+
+func TestNewTextStyle(t *testing.T) {
+	// A regular file stands in for redirected output
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatalf("cannot create file: %v", err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		name      string
+		format    string
+		width     int
+		color     string
+		frame     string
+		noColor   string
+		wantPlain bool
+		wantErr   string
+	}{
+		{name: "redirected output is plain", format: "text", color: "auto", frame: "none", wantPlain: true},
+		{name: "always colours", format: "text", color: "always", frame: "none", noColor: "1"},
+		{name: "width", format: "text", width: 60, color: "never", frame: "none"},
+		{name: "frame", format: "text", color: "never", frame: "double"},
+		{name: "invalid color", format: "text", color: "sometimes", frame: "none", wantErr: "invalid color"},
+		{name: "invalid frame", format: "text", color: "auto", frame: "heavy", wantErr: "invalid frame"},
+		{name: "negative width", format: "text", width: -1, color: "auto", frame: "none", wantErr: "must not be negative"},
+		{name: "frame needs text", format: "json", color: "auto", frame: "single", wantErr: "needs --format text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			textWidth, textColor, textColorTheme, textFrame = tt.width, tt.color, "default", tt.frame
+			defer func() { textWidth, textColor, textColorTheme, textFrame = 0, "auto", "default", "none" }()
+
+			style, err := newTextStyle(f, tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("newTextStyle() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newTextStyle() unexpected error: %v", err)
+			}
+			if style.isPlain() != tt.wantPlain {
+				t.Errorf("newTextStyle() = %+v, isPlain() = %v, want %v", style, style.isPlain(), tt.wantPlain)
+			}
+		})
+	}
+}
+
+func TestTextFlags(t *testing.T) {
+	output, err := executeCommand(newRootCommand(), "--author", "cook", "--width", "40", "--frame", "ascii", "--color", "always", "--color-theme", "plain")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if lines[0] != "+"+strings.Repeat("-", len(lines[0])-2)+"+" || len(lines[0]) > 40 {
+		t.Errorf("first line = %q, want an ASCII border at most 40 columns wide", lines[0])
+	}
+	if !strings.Contains(output, "\x1b[3mRick Cook\x1b[0m") {
+		t.Errorf("output doesn't colour the author:\n%s", output)
+	}
+
+	if _, err := executeCommand(newRootCommand(), "list", "--format", "json", "--width", "40"); err == nil {
+		t.Error("expected an error for --width with --format json")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	// Embed the timezone database so --tz works the same on every machine
+	_ "time/tzdata"
+
+	"github.com/spf13/cobra"
+)
+
+// now returns the current time; tests replace it to pin the clock
+var now = time.Now
+
+var (
+	todayPeriod   string
+	todayTimezone string
+	todayDate     string
+)
+
+// PeriodKey names the calendar period containing t: "2006-01-02" for a day,
+// the ISO week as "2006-W01" for a week, or "2006-01" for a month
+func PeriodKey(t time.Time, period string) (string, error) {
+	switch period {
+	case "day":
+		return t.Format("2006-01-02"), nil
+	case "week":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week), nil
+	case "month":
+		return t.Format("2006-01"), nil
+	default:
+		return "", fmt.Errorf("invalid period: %s (must be one of: day, week, month)", period)
+	}
+}
+
+// QuoteOfThePeriod picks the quote for the period containing t. The pick is
+// derived only from the period and the collection's contents, so everyone
+// with the same collection sees the same quote regardless of load order or
+// local clock. Returns ErrNoQuotes if the quotes slice is empty.
+func QuoteOfThePeriod(quotes []Quote, t time.Time, period string) (Quote, error) {
+	key, err := PeriodKey(t, period)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	// Order by ID so the pick doesn't depend on the order sources were loaded
+	sorted := append([]Quote(nil), quotes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	h := fnv.New64a()
+	h.Write([]byte(key + "\x00" + CollectionHash(sorted)))
+
+	return SelectRandom(sorted, int64(h.Sum64()))
+}
+
+// newTodayCommand creates the today subcommand, which prints the quote of
+// the current day, week or month
+func newTodayCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "today",
+		Short: "Print the quote of the day",
+		Long: `Print a deterministic quote for the current day, week or month.
+
+The pick depends only on the calendar period in the chosen timezone and on the
+contents of the collection, so everyone sharing a collection and timezone sees
+the same quote.`,
+		Args: cobra.NoArgs,
+		RunE: runToday,
+	}
+
+	addOutputFlags(cmd)
+	cmd.Flags().StringVar(&todayPeriod, "period", "day", "Period the quote stays fixed for: day|week|month")
+	cmd.Flags().StringVar(&todayTimezone, "tz", "UTC", "IANA timezone that decides when a period starts, e.g. Europe/Dublin or Local")
+	cmd.Flags().StringVar(&todayDate, "date", "", "Show the quote for this date (YYYY-MM-DD) instead of today")
+
+	return cmd
+}
+
+// runToday prints the quote of the current (or requested) period
+func runToday(cmd *cobra.Command, args []string) error {
+	out, err := newOutput()
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(todayTimezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %s", todayTimezone)
+	}
+
+	t := now().In(loc)
+	if todayDate != "" {
+		if t, err = time.ParseInLocation("2006-01-02", todayDate, loc); err != nil {
+			return fmt.Errorf("invalid date: %s (must be YYYY-MM-DD)", todayDate)
+		}
+	}
+
+	quotes, err := loadFilteredQuotes()
+	if err != nil {
+		return err
+	}
+
+	q, err := QuoteOfThePeriod(quotes, t, todayPeriod)
+	if err != nil {
+		return err
+	}
+
+	result, err := out.render([]Quote{q})
+	if err != nil {
+		return err
+	}
+	fmt.Print(result)
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPeriodKey(t *testing.T) {
+	// Sunday 2023-01-01 belongs to ISO week 52 of 2022
+	sunday := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		period  string
+		want    string
+		wantErr bool
+	}{
+		{period: "day", want: "2023-01-01"},
+		{period: "week", want: "2022-W52"},
+		{period: "month", want: "2023-01"},
+		{period: "year", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			got, err := PeriodKey(sunday, tt.period)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PeriodKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("PeriodKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteOfThePeriod(t *testing.T) {
+	quotes := withIDs(defaultQuotes)
+	day := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	first, err := QuoteOfThePeriod(quotes, day, "day")
+	if err != nil {
+		t.Fatalf("QuoteOfThePeriod() unexpected error: %v", err)
+	}
+
+	t.Run("same day same quote", func(t *testing.T) {
+		later, _ := QuoteOfThePeriod(quotes, day.Add(14*time.Hour), "day")
+		if later.ID != first.ID {
+			t.Errorf("quote changed within the day: %q vs %q", first.Text, later.Text)
+		}
+	})
+
+	t.Run("load order does not matter", func(t *testing.T) {
+		reversed := make([]Quote, len(quotes))
+		for i, q := range quotes {
+			reversed[len(quotes)-1-i] = q
+		}
+		got, _ := QuoteOfThePeriod(reversed, day, "day")
+		if got.ID != first.ID {
+			t.Errorf("reordered collection picked %q, want %q", got.Text, first.Text)
+		}
+	})
+
+	t.Run("days vary", func(t *testing.T) {
+		distinct := map[string]bool{}
+		for i := 0; i < 14; i++ {
+			q, _ := QuoteOfThePeriod(quotes, day.AddDate(0, 0, i), "day")
+			distinct[q.ID] = true
+		}
+		if len(distinct) < 5 {
+			t.Errorf("two weeks of days produced only %d distinct quotes", len(distinct))
+		}
+	})
+
+	t.Run("week is fixed across its days", func(t *testing.T) {
+		monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+		want, _ := QuoteOfThePeriod(quotes, monday, "week")
+		for i := 1; i < 7; i++ {
+			got, _ := QuoteOfThePeriod(quotes, monday.AddDate(0, 0, i), "week")
+			if got.ID != want.ID {
+				t.Errorf("quote changed on day %d of the week", i)
+			}
+		}
+	})
+
+	t.Run("collection changes the pick", func(t *testing.T) {
+		changed := 0
+		for i := 0; i < 10; i++ {
+			d := day.AddDate(0, 0, i)
+			a, _ := QuoteOfThePeriod(quotes, d, "day")
+			b, _ := QuoteOfThePeriod(quotes[1:], d, "day")
+			if a.ID != b.ID {
+				changed++
+			}
+		}
+		if changed == 0 {
+			t.Error("a different collection should yield different picks")
+		}
+	})
+
+	t.Run("empty collection", func(t *testing.T) {
+		if _, err := QuoteOfThePeriod(nil, day, "day"); err != ErrNoQuotes {
+			t.Errorf("QuoteOfThePeriod() error = %v, want ErrNoQuotes", err)
+		}
+	})
+}
+
+func TestTodayCommand(t *testing.T) {
+	isolateSources(t)
+
+	// 23:30 UTC on the 17th is already the 18th in Auckland
+	now = func() time.Time { return time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	run := func(args ...string) string {
+		t.Helper()
+		output, err := executeCommand(newRootCommand(), append([]string{"today"}, args...)...)
+		if err != nil {
+			t.Fatalf("today %v: unexpected error: %v", args, err)
+		}
+		return output
+	}
+
+	if run() != run("--date", "2026-10-17") {
+		t.Error("today in UTC should match --date 2026-10-17")
+	}
+	if run("--tz", "Pacific/Auckland") != run("--date", "2026-10-18") {
+		t.Error("today in Auckland should match --date 2026-10-18")
+	}
+	if !strings.HasPrefix(run("--format", "markdown"), "> ") {
+		t.Error("--format markdown should produce a blockquote")
+	}
+
+	tests := []struct {
+		args    []string
+		wantErr string
+	}{
+		{args: []string{"--tz", "Mars/Olympus_Mons"}, wantErr: "invalid timezone"},
+		{args: []string{"--period", "year"}, wantErr: "invalid period"},
+		{args: []string{"--date", "17/10/2026"}, wantErr: "invalid date"},
+		{args: []string{"--author", "Nobody"}, wantErr: "no quotes match"},
+	}
+
+	for _, tt := range tests {
+		_, err := executeCommand(newRootCommand(), append([]string{"today"}, tt.args...)...)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("today %v: expected error containing %q, got %v", tt.args, tt.wantErr, err)
+		}
+	}
+}
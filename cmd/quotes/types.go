@@ -1,14 +1,27 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"math/rand"
+	"sort"
+	"strings"
 )
 
-// Quote represents a motivational quote with its author
+// Quote represents a motivational quote with its author and optional metadata.
+// Only Text and Author are required; the remaining fields are omitted from JSON
+// output when empty so two-field collections round-trip unchanged.
 type Quote struct {
-	Text   string
-	Author string
+	ID       string   `json:"id,omitempty"`
+	Text     string   `json:"text"`
+	Author   string   `json:"author"`
+	Tags     []string `json:"tags,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	Year     int      `json:"year,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Language string   `json:"language,omitempty"`
+	Notes    string   `json:"notes,omitempty"`
 }
 
 // ErrNoQuotes is returned when attempting to select from an empty quote list
@@ -89,6 +102,44 @@ var defaultQuotes = []Quote{
 	{Text: "The greatest enemy of knowledge is not ignorance, it is the illusion of knowledge", Author: "Stephen Hawking"},
 }
 
+// QuoteID derives a stable identifier from a quote's text and author.
+// Case and whitespace differences are ignored so trivially reformatted
+// quotes keep the same ID.
+func QuoteID(text, author string) string {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+
+	sum := sha256.Sum256([]byte(normalize(author) + "\x00" + normalize(text)))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// withIDs returns a copy of quotes with a content-derived ID assigned to every
+// quote that doesn't already carry an explicit one.
+func withIDs(quotes []Quote) []Quote {
+	result := make([]Quote, len(quotes))
+	for i, q := range quotes {
+		if q.ID == "" {
+			q.ID = QuoteID(q.Text, q.Author)
+		}
+		result[i] = q
+	}
+	return result
+}
+
+// CollectionHash derives a fingerprint of a collection from its quote IDs.
+// It ignores order, so it only changes when quotes are added or removed.
+func CollectionHash(quotes []Quote) string {
+	ids := make([]string, len(quotes))
+	for i, q := range quotes {
+		ids[i] = q.ID
+	}
+	sort.Strings(ids)
+
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
 // SelectRandom returns a random quote from the provided slice using the given seed
 // for reproducible randomness. Returns ErrNoQuotes if the quotes slice is empty.
 func SelectRandom(quotes []Quote, seed int64) (Quote, error) {
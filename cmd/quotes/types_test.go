@@ -28,6 +28,99 @@ func TestQuoteStruct(t *testing.T) {
 	}
 }
 
+func TestQuoteID(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      Quote
+		wantEqual bool
+	}{
+		{
+			name:      "identical quotes",
+			a:         Quote{Text: "Code is poetry", Author: "Unknown"},
+			b:         Quote{Text: "Code is poetry", Author: "Unknown"},
+			wantEqual: true,
+		},
+		{
+			name:      "case and whitespace ignored",
+			a:         Quote{Text: "Code is poetry", Author: "Unknown"},
+			b:         Quote{Text: "  code IS   poetry ", Author: "unknown"},
+			wantEqual: true,
+		},
+		{
+			name:      "different author",
+			a:         Quote{Text: "Code is poetry", Author: "Unknown"},
+			b:         Quote{Text: "Code is poetry", Author: "Anonymous"},
+			wantEqual: false,
+		},
+		{
+			name:      "text and author not interchangeable",
+			a:         Quote{Text: "a b", Author: "c"},
+			b:         Quote{Text: "b", Author: "a c"},
+			wantEqual: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idA := QuoteID(tt.a.Text, tt.a.Author)
+			idB := QuoteID(tt.b.Text, tt.b.Author)
+
+			if len(idA) != 8 {
+				t.Errorf("QuoteID() = %q, want 8 hex characters", idA)
+			}
+
+			if (idA == idB) != tt.wantEqual {
+				t.Errorf("QuoteID() equality = %v, want %v (%q vs %q)", idA == idB, tt.wantEqual, idA, idB)
+			}
+		})
+	}
+}
+
+func TestWithIDs(t *testing.T) {
+	quotes := []Quote{
+		{Text: "Code is poetry", Author: "Unknown"},
+		{ID: "custom", Text: "Stay hungry", Author: "Steve Jobs"},
+	}
+
+	got := withIDs(quotes)
+
+	if got[0].ID != QuoteID("Code is poetry", "Unknown") {
+		t.Errorf("withIDs() assigned %q, want content-derived ID", got[0].ID)
+	}
+	if got[1].ID != "custom" {
+		t.Errorf("withIDs() replaced explicit ID with %q", got[1].ID)
+	}
+	if quotes[0].ID != "" {
+		t.Error("withIDs() modified its input")
+	}
+}
+
+func TestDefaultQuotes_UniqueIDs(t *testing.T) {
+	seen := make(map[string]string)
+	for _, q := range withIDs(defaultQuotes) {
+		if other, ok := seen[q.ID]; ok {
+			t.Errorf("ID %s shared by %q and %q", q.ID, other, q.Text)
+		}
+		seen[q.ID] = q.Text
+	}
+}
+
+func TestCollectionHash(t *testing.T) {
+	quotes := withIDs(sampleQuotes)
+	reversed := make([]Quote, len(quotes))
+	for i, q := range quotes {
+		reversed[len(quotes)-1-i] = q
+	}
+
+	if CollectionHash(quotes) != CollectionHash(reversed) {
+		t.Error("CollectionHash() should not depend on order")
+	}
+
+	if CollectionHash(quotes) == CollectionHash(quotes[1:]) {
+		t.Error("CollectionHash() should change when a quote is removed")
+	}
+}
+
 func TestRandomSelection_DeterministicSeed(t *testing.T) {
 	seed := int64(42)
 
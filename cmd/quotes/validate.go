@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// newValidateCommand creates the validate subcommand, which checks quotes
+// files and reports every problem with its line and column
+func newValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [file]",
+		Short: "Check quotes files for errors",
+		Long: `Validate a quotes file, reporting JSON syntax errors and per-entry problems
+with line and column. Without an argument, every file in the source chain
+(--file, or QUOTES_PATH, $XDG_CONFIG_HOME/quotes and ~/.quotes.json) is checked.`,
+		Args: cobra.MaximumNArgs(1),
+		// Problems with the file are not usage errors
+		SilenceUsage: true,
+		RunE:         runValidate,
+	}
+}
+
+// runValidate validates the named file, or every resolved source by default
+func runValidate(cmd *cobra.Command, args []string) error {
+	paths := args
+	if len(paths) == 0 {
+		var err error
+		if paths, err = ResolveSources(quoteFiles); err != nil {
+			return err
+		}
+	}
+
+	if len(paths) == 0 {
+		fmt.Println("no quote files found, using built-in quotes")
+		return nil
+	}
+
+	problems := 0
+	for _, path := range paths {
+		file, err := ReadQuoteFile(path)
+
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			fmt.Println(validationErr.Error())
+			problems += len(validationErr.Problems)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: ok (%d quotes, %s)\n", path, len(file.Quotes), file.Mode)
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// wideRanges are the East Asian Wide and Fullwidth characters and emoji that
+// terminals draw two columns wide, in ascending order
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F2FF}, {0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF}, {0x1F7E0, 0x1F7EB}, {0x1F90C, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// runeWidth returns the number of terminal columns r occupies: 0 for control
+// characters and combining marks, 2 for wide characters and 1 otherwise
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20, r >= 0x7F && r < 0xA0:
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf), r >= 0x1160 && r <= 0x11FF:
+		return 0
+	}
+
+	i := sort.Search(len(wideRanges), func(i int) bool { return wideRanges[i].hi >= r })
+	if i < len(wideRanges) && wideRanges[i].lo <= r {
+		return 2
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal columns s occupies
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// wrapLines breaks text into lines at most width columns wide at spaces and
+// between wide characters, keeping existing line breaks. Words wider than
+// width get a line of their own. A width below 1 only splits at the existing
+// line breaks.
+func wrapLines(width int, text string) []string {
+	paragraphs := strings.Split(text, "\n")
+	if width < 1 {
+		return paragraphs
+	}
+
+	var lines []string
+	for _, paragraph := range paragraphs {
+		var line strings.Builder
+		lineWidth := 0
+		for _, p := range breakPieces(paragraph) {
+			gap := 0
+			if p.afterSpace {
+				gap = 1
+			}
+			switch {
+			case lineWidth == 0:
+				line.WriteString(p.text)
+				lineWidth = p.width
+			case lineWidth+gap+p.width <= width:
+				line.WriteString(strings.Repeat(" ", gap) + p.text)
+				lineWidth += gap + p.width
+			default:
+				lines = append(lines, line.String())
+				line.Reset()
+				line.WriteString(p.text)
+				lineWidth = p.width
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// breakPiece is a run of text that wrapLines keeps on one line
+type breakPiece struct {
+	text       string
+	width      int
+	afterSpace bool
+}
+
+// breakPieces splits paragraph into the pieces a line may break between:
+// words, and each wide character on its own
+func breakPieces(paragraph string) []breakPiece {
+	var pieces []breakPiece
+	for i, word := range strings.Fields(paragraph) {
+		afterSpace := i > 0
+		start, width := 0, 0
+		for j, r := range word {
+			w := runeWidth(r)
+			if w < 2 {
+				width += w
+				continue
+			}
+			if j > start {
+				pieces = append(pieces, breakPiece{word[start:j], width, afterSpace})
+				afterSpace = false
+			}
+			end := j + utf8.RuneLen(r)
+			pieces = append(pieces, breakPiece{word[j:end], w, afterSpace})
+			afterSpace = false
+			start, width = end, 0
+		}
+		if start < len(word) {
+			pieces = append(pieces, breakPiece{word[start:], width, afterSpace})
+		}
+	}
+	return pieces
+}
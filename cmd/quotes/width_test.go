@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"code", 4},
+		{"café", 4},
+		{"cafe\u0301", 4},
+		{"日本語", 6},
+		{"ｺｰﾄﾞ", 4},
+		{"ＡＢ", 4},
+		{"한국어", 6},
+		{"ok 👍", 5},
+		{"a\u200bb", 2},
+		{"\t", 0},
+	}
+
+	for _, tt := range tests {
+		if got := displayWidth(tt.text); got != tt.want {
+			t.Errorf("displayWidth(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		text  string
+		want  []string
+	}{
+		{"fits", 20, "Talk is cheap.", []string{"Talk is cheap."}},
+		{"breaks at spaces", 10, "Talk is cheap. Show me the code.", []string{"Talk is", "cheap.", "Show me", "the code."}},
+		{"collapses spaces", 20, "Talk   is  cheap.", []string{"Talk is cheap."}},
+		{"keeps line breaks", 20, "One\n\nTwo", []string{"One", "", "Two"}},
+		{"wide characters", 9, "日本 語の 引用", []string{"日本 語の", "引用"}},
+		{"breaks between wide characters", 8, "日本語のテキスト", []string{"日本語の", "テキスト"}},
+		{"mixed scripts", 10, "Go言語はsimple", []string{"Go言語は", "simple"}},
+		{"long word", 4, "a supercalifragilistic b", []string{"a", "supercalifragilistic", "b"}},
+		{"no width", 0, "left  alone\nhere", []string{"left  alone", "here"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapLines(tt.width, tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapLines(%d, %q) = %q, want %q", tt.width, tt.text, got, tt.want)
+			}
+		})
+	}
+}